@@ -0,0 +1,185 @@
+package gorm_logrus
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// asyncDropReportInterval the interval between the reports of the entries dropped by the async mode
+const asyncDropReportInterval = 10 * time.Second
+
+// WithAsync emit the entries from a background goroutine through a buffer of bufferSize entries, whatever
+// the logger emitting them, e.g. WithSlowLogger, built synchronously so that fc and the caller resolution keep
+// running on the query path, an entry is dropped rather than blocking when the buffer is full, the drops being
+// reported at Warn periodically, see Flush and Close
+func WithAsync(bufferSize int) Option {
+	return func(opt *options) {
+		opt.asyncBufferSize = bufferSize
+	}
+}
+
+type (
+	// asyncQueue the buffer of the entries emitted from a goroutine, the drops being reported to report
+	asyncQueue struct {
+		report  ContextLogger
+		entries chan asyncEntry
+		done    chan struct{}
+		stopped chan struct{}
+		// mu guards closed, read locked by push so that no entry is queued once close returns from the lock
+		mu      sync.RWMutex
+		closed  bool
+		dropped uint64
+	}
+	// asyncBackend a ContextLogger emitting the entries of backend through queue
+	asyncBackend struct {
+		queue   *asyncQueue
+		backend ContextLogger
+	}
+	asyncEntry struct {
+		backend ContextLogger
+		ctx     context.Context
+		level   logrus.Level
+		fields  logrus.Fields
+		msg     string
+		// flushed closed once the entries queued before it are emitted, set for the Flush markers only
+		flushed chan struct{}
+	}
+)
+
+func newAsyncQueue(report ContextLogger, bufferSize int) *asyncQueue {
+	q := &asyncQueue{
+		report:  report,
+		entries: make(chan asyncEntry, bufferSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (b asyncBackend) Enabled(level logrus.Level) bool {
+	return b.backend.Enabled(level)
+}
+
+func (b asyncBackend) enabled(ctx context.Context, level logrus.Level) bool {
+	return enabled(b.backend, ctx, level)
+}
+
+func (b asyncBackend) colored(ctx context.Context) bool {
+	return colored(b.backend, ctx)
+}
+
+// Log queue a copy of fields, which the caller may keep writing to, e.g. a layer shared by mergeFields
+func (b asyncBackend) Log(ctx context.Context, level logrus.Level, fields logrus.Fields, msg string) {
+	b.queue.push(asyncEntry{backend: b.backend, ctx: ctx, level: level, fields: cloneFields(fields), msg: msg})
+}
+
+// push queue e, dropping it when the queue is full or closed
+func (q *asyncQueue) push(e asyncEntry) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		atomic.AddUint64(&q.dropped, 1)
+		return
+	}
+	select {
+	case q.entries <- e:
+	default:
+		atomic.AddUint64(&q.dropped, 1)
+	}
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.stopped)
+	ticker := time.NewTicker(asyncDropReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-q.entries:
+			q.emit(e)
+		case <-ticker.C:
+			q.reportDropped()
+		case <-q.done:
+			for {
+				select {
+				case e := <-q.entries:
+					q.emit(e)
+				default:
+					q.reportDropped()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *asyncQueue) emit(e asyncEntry) {
+	if e.flushed != nil {
+		close(e.flushed)
+		return
+	}
+	e.backend.Log(e.ctx, e.level, e.fields, e.msg)
+}
+
+func (q *asyncQueue) reportDropped() {
+	if dropped := atomic.SwapUint64(&q.dropped, 0); dropped > 0 && q.report.Enabled(logrus.WarnLevel) {
+		q.report.Log(context.Background(), logrus.WarnLevel, logrus.Fields{"dropped": dropped},
+			fmt.Sprintf("dropped %d gorm log entries", dropped))
+	}
+}
+
+// flush wait until the entries queued so far are emitted, or ctx is done
+func (q *asyncQueue) flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	select {
+	case q.entries <- asyncEntry{flushed: flushed}:
+	case <-q.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-q.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close emit the queued entries and stop the goroutine, later entries are dropped
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+	q.mu.Unlock()
+	<-q.stopped
+}
+
+// Flush wait until the slow query EXPLAIN and the entries queued by the async mode so far are emitted, or ctx is done
+func (l *Logger) Flush(ctx context.Context) error {
+	if err := l.explainer.flush(ctx); err != nil {
+		return err
+	}
+	if l.async == nil {
+		return nil
+	}
+	return l.async.flush(ctx)
+}
+
+// Close emit the entries queued by the async mode and stop the background work of the logger,
+// safe to call several times, the entries logged afterwards are dropped in async mode
+func (l *Logger) Close() error {
+	l.Stop()
+	if l.async != nil {
+		l.async.close()
+	}
+	return nil
+}
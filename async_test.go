@@ -0,0 +1,196 @@
+package gorm_logrus
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingBackend a ContextLogger recording the messages it emits, blocking on release when set
+type recordingBackend struct {
+	mu       sync.Mutex
+	msgs     []string
+	received chan struct{}
+	release  chan struct{}
+}
+
+func (b *recordingBackend) Enabled(logrus.Level) bool {
+	return true
+}
+
+func (b *recordingBackend) Log(_ context.Context, _ logrus.Level, _ logrus.Fields, msg string) {
+	if b.received != nil {
+		b.received <- struct{}{}
+	}
+	if b.release != nil {
+		<-b.release
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.msgs = append(b.msgs, msg)
+}
+
+func (b *recordingBackend) messages() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.msgs...)
+}
+
+func TestAsyncConcurrentTrace(t *testing.T) {
+	backend := &recordingBackend{}
+	l := New(WithBackend(backend), WithAsync(1000)).(*Logger)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				sql := fmt.Sprintf("SELECT %d", i*100+j)
+				l.Trace(context.Background(), time.Now(), func() (string, int64) { return sql, 1 }, nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(backend.messages()); got != 800 {
+		t.Errorf("got %d entries after Flush, want 800", got)
+	}
+	l.Close()
+}
+
+func TestAsyncDropsWhenSaturated(t *testing.T) {
+	backend := &recordingBackend{received: make(chan struct{}, 100), release: make(chan struct{})}
+	l := New(WithBackend(backend), WithAsync(1)).(*Logger)
+	trace := func() {
+		l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	}
+
+	// the goroutine blocks on the first entry, the second one fills the buffer, the others are dropped
+	trace()
+	<-backend.received
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		trace()
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Trace blocked %v on the saturated buffer", elapsed)
+	}
+	close(backend.release)
+	l.Close()
+
+	msgs := backend.messages()
+	if len(msgs) != 3 || msgs[2] != "dropped 9 gorm log entries" {
+		t.Errorf("got %q, want 2 queries and the report of the 9 dropped entries", msgs)
+	}
+	trace()
+	if got := len(backend.messages()); got != 3 {
+		t.Errorf("got %d entries, want the entry logged after Close dropped", got)
+	}
+}
+
+func TestAsyncRoutedLoggers(t *testing.T) {
+	primary, primaryBuf := newBufferLogger()
+	slowLog, slowBuf := newBufferLogger()
+	l := New(WithLogger(primary), WithSlowLogger(slowLog), WithSlowThreshold(time.Second), WithAsync(10)).(*Logger)
+	defer l.Close()
+
+	var mu sync.Mutex
+	slowLog.AddHook(&lockHook{mu: &mu})
+	mu.Lock()
+	// the slow logger is held by its hook, so a synchronous slow query log would block here
+	l.Trace(context.Background(), slowBegin(time.Second), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	mu.Unlock()
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if primaryBuf.Len() != 0 || !strings.Contains(slowBuf.String(), "SELECT 1") {
+		t.Errorf("got %q and %q, want the slow query in the slow logger after Flush", primaryBuf.String(), slowBuf.String())
+	}
+}
+
+// lockHook a logrus hook waiting for mu
+type lockHook struct {
+	mu *sync.Mutex
+}
+
+func (h *lockHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *lockHook) Fire(*logrus.Entry) error {
+	h.mu.Lock()
+	h.mu.Unlock()
+	return nil
+}
+
+func TestAsyncSlowQueryLogger(t *testing.T) {
+	primary, primaryBuf := newBufferLogger()
+	slowLog, slowBuf := newBufferLogger()
+	l := New(
+		WithLogger(primary),
+		WithAsync(1000),
+		WithSlowQueryLogger(slowLog),
+		WithSlowQueryLogOnly(false),
+		WithQueryMessage("sql"),
+		WithSlowMessage("slow sql"),
+		WithSlowThreshold(time.Millisecond),
+	).(*Logger)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.Trace(context.Background(), slowBegin(time.Millisecond), func() (string, int64) { return "SELECT 1", 1 }, nil)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for name, buf := range map[string]string{"primary": primaryBuf.String(), "slow": slowBuf.String()} {
+		if got := strings.Count(buf, `"sql":"SELECT 1"`); got != 400 {
+			t.Errorf("got %d slow queries in the %s logger, want 400", got, name)
+		}
+	}
+}
+
+func TestAsyncPushRacingClose(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		backend, report := &recordingBackend{}, &recordingBackend{}
+		q := newAsyncQueue(report, 1000)
+		var wg sync.WaitGroup
+		for j := 0; j < 8; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 50; k++ {
+					q.push(asyncEntry{backend: backend, ctx: context.Background(), msg: "SELECT 1"})
+				}
+			}()
+		}
+		q.close()
+		wg.Wait()
+
+		// every entry is either emitted or counted as dropped, reported or not
+		dropped := int(atomic.LoadUint64(&q.dropped))
+		for _, msg := range report.messages() {
+			var n int
+			if _, err := fmt.Sscanf(msg, "dropped %d gorm log entries", &n); err != nil {
+				t.Fatal(err)
+			}
+			dropped += n
+		}
+		if got := len(backend.messages()) + dropped; got != 400 {
+			t.Fatalf("got %d entries emitted or dropped, want 400", got)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package gorm_logrus
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+)
+
+// WithAuditMode log only the write queries, INSERT, UPDATE, DELETE, REPLACE, MERGE and UPSERT,
+// at the WithAuditLevel level whatever their elapsed time, every other query is skipped unless it fails
+// or is slow, the slow reads, and the slow writes when the audit level is disabled, being logged as slow
+// queries, the WithExcludeSQL and WithIncludeSQL filters apply to the audited writes, while the sampling
+// and WithRateLimit don't
+func WithAuditMode(enabled bool) Option {
+	return func(opt *options) {
+		opt.auditMode = enabled
+	}
+}
+
+// WithAuditLevel set the level of the writes logged in audit mode, default Info
+func WithAuditLevel(level logrus.Level) Option {
+	return func(opt *options) {
+		opt.auditLevel = &level
+	}
+}
+
+// auditBranchLevel return the level of the writes logged in audit mode
+func (l *Logger) auditBranchLevel() logrus.Level {
+	if l.auditLevel != nil {
+		return *l.auditLevel
+	}
+	return logrus.InfoLevel
+}
+
+// traceAudit log a successful write in audit mode, the slow queries not audited going to the slow branch
+func (l *Logger) traceAudit(t *traceCall, gormLevel logger.LogLevel) {
+	backend := l.branchBackend(BranchQuery)
+	level := l.auditBranchLevel()
+	audited := l.levelEnabled(t.ctx, backend, level)
+	maybeSlow := gormLevel >= logger.Warn && l.maybeSlow(t.ctx, t.elapsed) && l.slowBranchEnabled(t.ctx)
+	if !audited && !maybeSlow {
+		return
+	}
+	sql, rows := t.result()
+	if !audited || queryKind(sql) != QueryKindWrite {
+		if maybeSlow && l.slowLogged(t, gormLevel) {
+			l.traceSlow(t)
+		}
+		return
+	}
+	if l.filteredSQL(sql) {
+		return
+	}
+	fields := l.traceFields(t)
+	if l.isSlow(t) {
+		fields["slowLog"] = l.slowLog(t)
+	}
+	l.logTrace(backend, t.ctx, level, fields, l.queryMessage, t.elapsed, l.formatSQL(sql), rows)
+}
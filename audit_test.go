@@ -0,0 +1,59 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"testing"
+	"time"
+)
+
+func TestAuditMode(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		sql   string
+		slow  bool
+		level logrus.Level
+	}{
+		{name: "write", sql: "UPDATE `users` SET `name` = 'x'", level: logrus.InfoLevel},
+		{name: "slow write", sql: "UPDATE `users` SET `name` = 'x'", slow: true, level: logrus.InfoLevel},
+		{name: "read skipped", sql: "SELECT * FROM `users`"},
+		{name: "slow read", sql: "SELECT * FROM `users`", slow: true, level: logrus.WarnLevel},
+		{name: "excluded write", opts: []Option{WithExcludeSQL("`users`")}, sql: "UPDATE `users` SET `name` = 'x'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, hook := newTestLogger(append(tt.opts, WithAuditMode(true), WithSlowThreshold(time.Second))...)
+			begin := time.Now()
+			if tt.slow {
+				begin = slowBegin(time.Second)
+			}
+			l.Trace(context.Background(), begin, func() (string, int64) { return tt.sql, 1 }, nil)
+			entry := hook.LastEntry()
+			switch {
+			case tt.level == 0 && entry != nil:
+				t.Errorf("got %s %q, want the query skipped", entry.Level, entry.Message)
+			case tt.level != 0 && (entry == nil || entry.Level != tt.level):
+				t.Errorf("got %v, want the query logged at %s", entry, tt.level)
+			case tt.slow && entry.Data["slowLog"] == nil:
+				t.Errorf("got fields %v, want the slowLog field", entry.Data)
+			}
+		})
+	}
+}
+
+func TestAuditModeSlowReadWithAuditLevelDisabled(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	log.SetLevel(logrus.WarnLevel)
+	l := New(WithLogger(log), WithAuditMode(true), WithSlowThreshold(time.Second))
+	fc, calls := countingTrace("UPDATE `users` SET `name` = 'x'", 1)
+	l.Trace(context.Background(), time.Now(), fc, nil)
+	if *calls != 0 {
+		t.Errorf("fc called %d times, want the fast write skipped before any work", *calls)
+	}
+	l.Trace(context.Background(), slowBegin(time.Second), fc, nil)
+	if entry := hook.LastEntry(); entry == nil || entry.Level != logrus.WarnLevel {
+		t.Errorf("got %v, want the slow write logged at Warn", entry)
+	}
+}
@@ -0,0 +1,199 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+)
+
+// ContextLogger the backend the Logger emits its entries to, the default one logs
+// with the *logrus.Logger set by WithLogger, implement it to log elsewhere, e.g. with zap
+type ContextLogger interface {
+	// Enabled report whether entries at level are emitted, Log isn't called otherwise
+	Enabled(level logrus.Level) bool
+	// Log emit an entry, fields may be nil
+	Log(ctx context.Context, level logrus.Level, fields logrus.Fields, msg string)
+}
+
+// WithBackend set the backend of the logger, overriding WithLogger and WithEntry, as well as the loggers of
+// WithLoggerRouting, WithErrorLogger, WithSlowLogger and WithSlowQueryLogger, every entry being emitted to it
+func WithBackend(backend ContextLogger) Option {
+	return func(opt *options) {
+		opt.backend = backend
+	}
+}
+
+// WithDisableContext stop attaching the context to the logrus entries of the default backend,
+// saving the WithContext call when no hook or formatter uses it
+func WithDisableContext(disable bool) Option {
+	return func(opt *options) {
+		opt.disableContext = disable
+	}
+}
+
+// WithContextRewriter set a func deriving the context used by each logging method from the one given by gorm,
+// e.g. to strip the values that the context reading hooks must not see
+func WithContextRewriter(rewriter func(ctx context.Context) context.Context) Option {
+	return func(opt *options) {
+		opt.contextRewriter = rewriter
+	}
+}
+
+func (l *Logger) rewriteContext(ctx context.Context) context.Context {
+	if l.contextRewriter == nil {
+		return ctx
+	}
+	return l.contextRewriter(ctx)
+}
+
+// logrusBackend the default ContextLogger, backed by a *logrus.Logger, or by a *logrus.Entry when set
+// so that its fields are logged as well
+type logrusBackend struct {
+	log       *logrus.Logger
+	entry     *logrus.Entry
+	noContext bool
+	colorful  bool
+}
+
+// colorer implemented by the backends telling whether the traced queries they emit are colored
+type colorer interface {
+	colored(ctx context.Context) bool
+}
+
+// colored report whether the traced queries emitted by backend are colored, see colorful
+func colored(backend ContextLogger, ctx context.Context) bool {
+	c, ok := backend.(colorer)
+	return ok && c.colored(ctx)
+}
+
+// contextEnabler implemented by the backends whose enabled levels depend on the context of the call
+type contextEnabler interface {
+	enabled(ctx context.Context, level logrus.Level) bool
+}
+
+// enabled report whether backend emits the entries at level for ctx, see ContextLogger.Enabled
+func enabled(backend ContextLogger, ctx context.Context, level logrus.Level) bool {
+	if e, ok := backend.(contextEnabler); ok {
+		return e.enabled(ctx, level)
+	}
+	return backend.Enabled(level)
+}
+
+// logrusBackend return the default backend emitting to log, with the fields of the WithEntry entry
+func (opt *options) logrusBackend(log *logrus.Logger) logrusBackend {
+	entry := opt.entry
+	if entry != nil && entry.Logger != log {
+		entry = logrus.NewEntry(log).WithFields(entry.Data)
+	}
+	return logrusBackend{log: log, entry: entry, noContext: opt.disableContext, colorful: colorful(opt.cfg, log)}
+}
+
+// chainBackend wrap backend with the WithContextLogger lookup and the async mode, target being the logger
+// backend emits to, the one the request scoped entries are then emitted to as well, nil for the primary backend
+func (opt *options) chainBackend(backend ContextLogger, target *logrus.Logger) ContextLogger {
+	if opt.contextLogger != nil {
+		backend = contextLoggerBackend{lookup: opt.contextLogger, fallback: backend, target: target, noContext: opt.disableContext, colors: opt.cfg.Colorful}
+	}
+	if opt.async != nil {
+		backend = asyncBackend{queue: opt.async, backend: backend}
+	}
+	return backend
+}
+
+func (b logrusBackend) Enabled(level logrus.Level) bool {
+	return b.log.IsLevelEnabled(level)
+}
+
+func (b logrusBackend) Log(ctx context.Context, level logrus.Level, fields logrus.Fields, msg string) {
+	entry := b.entry
+	if entry == nil {
+		entry = logrus.NewEntry(b.log)
+	}
+	if !b.noContext {
+		entry = entry.WithContext(ctx)
+	}
+	if len(fields) > 0 {
+		entry = entry.WithFields(fields)
+	}
+	entry.Log(level, msg)
+}
+
+func (b logrusBackend) colored(context.Context) bool {
+	return b.colorful
+}
+
+// WithContextLogger log through the *logrus.Entry returned by lookup from the context of each call, e.g. the
+// request scoped entry stored by a middleware, instead of the logger set by WithLogger or WithEntry, nil falling
+// back to it, the per-call fields are merged onto the entry and the level must be enabled by the logger of the entry,
+// the branches routed to their own logger, e.g. by WithSlowLogger, still emit to it with the entry fields
+func WithContextLogger(lookup func(ctx context.Context) *logrus.Entry) Option {
+	return func(opt *options) {
+		opt.contextLogger = lookup
+	}
+}
+
+// contextLoggerBackend a ContextLogger logging through the entry found in the context, or fallback,
+// to target with the fields of the entry when set
+type contextLoggerBackend struct {
+	lookup    func(ctx context.Context) *logrus.Entry
+	fallback  ContextLogger
+	target    *logrus.Logger
+	noContext bool
+	// colors the Colorful field of the config
+	colors bool
+}
+
+func (b contextLoggerBackend) Enabled(level logrus.Level) bool {
+	return b.fallback.Enabled(level)
+}
+
+// enabled check level against the logger the entry found in ctx is emitted to, or the fallback one
+func (b contextLoggerBackend) enabled(ctx context.Context, level logrus.Level) bool {
+	entry := b.lookup(ctx)
+	switch {
+	case entry == nil:
+		return enabled(b.fallback, ctx, level)
+	case b.target != nil:
+		return b.target.IsLevelEnabled(level)
+	default:
+		return entry.Logger.IsLevelEnabled(level)
+	}
+}
+
+func (b contextLoggerBackend) Log(ctx context.Context, level logrus.Level, fields logrus.Fields, msg string) {
+	entry := b.lookup(ctx)
+	if entry == nil {
+		b.fallback.Log(ctx, level, fields, msg)
+		return
+	}
+	if b.target != nil {
+		entry = logrus.NewEntry(b.target).WithFields(entry.Data)
+	}
+	if entry.Logger.IsLevelEnabled(level) {
+		logrusBackend{log: entry.Logger, entry: entry, noContext: b.noContext}.Log(ctx, level, fields, msg)
+	}
+}
+
+func (b contextLoggerBackend) colored(ctx context.Context) bool {
+	entry := b.lookup(ctx)
+	if entry == nil || b.target != nil {
+		return colored(b.fallback, ctx)
+	}
+	return b.colors && colorful(logger.Config{Colorful: true}, entry.Logger)
+}
+
+// WithSlowQueryLogger log the slow queries to log as well, like a slow query log file, in addition to the
+// logger of the slow branch: the primary one, or the WithLoggerRouting or WithSlowLogger one
+func WithSlowQueryLogger(log *logrus.Logger) Option {
+	return func(opt *options) {
+		opt.slowLogger = log
+	}
+}
+
+// WithSlowQueryLogOnly log the slow queries to the WithSlowQueryLogger logger only, instead of in addition to the
+// logger of the slow branch, winning over WithSlowLogger and WithLoggerRouting, their EXPLAIN plans included
+func WithSlowQueryLogOnly(only bool) Option {
+	return func(opt *options) {
+		opt.slowLogOnly = only
+	}
+}
@@ -0,0 +1,79 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContextLoggerLevelEnabled(t *testing.T) {
+	primary, primaryBuf := newBufferLogger()
+	primary.SetLevel(logrus.WarnLevel)
+	verbose, verboseBuf := newBufferLogger()
+	quiet, quietBuf := newBufferLogger()
+	quiet.SetLevel(logrus.ErrorLevel)
+
+	// each key resolves to its own request scoped entry, at its own level
+	type verboseKey struct{}
+	type quietKey struct{}
+	l := New(WithLogger(primary), WithContextLogger(func(ctx context.Context) *logrus.Entry {
+		if entry, ok := ctx.Value(verboseKey{}).(*logrus.Entry); ok {
+			return entry
+		}
+		entry, _ := ctx.Value(quietKey{}).(*logrus.Entry)
+		return entry
+	}))
+
+	// the request scoped logger at Trace logs the query skipped by the primary one at Warn
+	fc, calls := countingTrace("SELECT 'verbose'", 1)
+	l.Trace(context.WithValue(context.Background(), verboseKey{}, verbose.WithField("request_id", "r1")), time.Now(), fc, nil)
+	if *calls != 1 || !strings.Contains(verboseBuf.String(), "SELECT 'verbose'") {
+		t.Errorf("got %q, fc called %d times, want the query logged by the request scoped logger", verboseBuf.String(), *calls)
+	}
+
+	// the request scoped logger at Error skips the query, fc isn't called
+	fc, calls = countingTrace("SELECT 'quiet'", 1)
+	l.Trace(context.WithValue(context.Background(), quietKey{}, quiet.WithField("request_id", "r2")), time.Now(), fc, nil)
+	if *calls != 0 || quietBuf.Len() != 0 {
+		t.Errorf("got %q, fc called %d times, want the query skipped by the request scoped logger", quietBuf.String(), *calls)
+	}
+
+	l.Info(context.WithValue(context.Background(), verboseKey{}, verbose.WithField("request_id", "r3")), "info message")
+	if !strings.Contains(verboseBuf.String(), "info message") || primaryBuf.Len() != 0 {
+		t.Errorf("got %q and %q, want the message logged by the request scoped logger only", verboseBuf.String(), primaryBuf.String())
+	}
+}
+
+func TestDisableContext(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	for _, disable := range []bool{false, true} {
+		l, hook := newTestLogger(WithDisableContext(disable))
+		l.Info(ctx, "info message")
+		if got := hook.LastEntry().Context; (got == ctx) == disable {
+			t.Errorf("got the entry context %v with WithDisableContext(%v)", got, disable)
+		}
+	}
+}
+
+func BenchmarkDisableContext(b *testing.B) {
+	for name, disable := range map[string]bool{"with context": false, "without context": true} {
+		b.Run(name, func(b *testing.B) {
+			log := logrus.New()
+			log.SetOutput(ioutil.Discard)
+			log.SetLevel(logrus.DebugLevel)
+			l := New(WithLogger(log), WithLogLevel(logger.Info), WithDisableContext(disable))
+			ctx := context.Background()
+			fc := func() (string, int64) { return "SELECT * FROM users WHERE id = 1", 1 }
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l.Trace(ctx, time.Now(), fc, nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package gorm_logrus
+
+import (
+	"context"
+)
+
+type (
+	batchKey  struct{}
+	batchInfo struct {
+		size, index int
+	}
+)
+
+// WithBatchFields log the batch_size and batch_index fields of the statements run as part of a batch,
+// gorm doesn't expose them so they must be stored in the statement context by a companion plugin
+// or by the caller with WithBatch, the fields are omitted otherwise
+func WithBatchFields(enabled bool) Option {
+	return func(opt *options) {
+		opt.batchFields = enabled
+	}
+}
+
+// WithBatch return a copy of ctx carrying the size of the batch and the index of the current one,
+// e.g. from a plugin splitting CreateInBatches or a manual batching loop
+//
+//	db.WithContext(gorm_logrus.WithBatch(ctx, len(batch), i)).Create(batch)
+func WithBatch(ctx context.Context, size, index int) context.Context {
+	return context.WithValue(ctx, batchKey{}, batchInfo{size: size, index: index})
+}
+
+func batchFrom(ctx context.Context) (batchInfo, bool) {
+	if ctx == nil {
+		return batchInfo{}, false
+	}
+	info, ok := ctx.Value(batchKey{}).(batchInfo)
+	return info, ok
+}
@@ -0,0 +1,152 @@
+package gorm_logrus
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Branch the kind of an emission of the Logger, see WithLevelMapper
+type Branch int
+
+const (
+	// BranchQuery a successful query traced by Trace
+	BranchQuery Branch = iota
+	// BranchSlow a slow query traced by Trace
+	BranchSlow
+	// BranchError a failed query traced by Trace
+	BranchError
+	// BranchInfo a message logged by Info
+	BranchInfo
+	// BranchWarn a message logged by Warn
+	BranchWarn
+	// BranchErrorMsg a message logged by Error
+	BranchErrorMsg
+)
+
+var (
+	branchNames = map[Branch]string{
+		BranchQuery:    "query",
+		BranchSlow:     "slow",
+		BranchError:    "error",
+		BranchInfo:     "info",
+		BranchWarn:     "warn",
+		BranchErrorMsg: "error_msg",
+	}
+	defaultBranchLevels = map[Branch]logrus.Level{
+		BranchQuery:    logrus.DebugLevel,
+		BranchSlow:     logrus.WarnLevel,
+		BranchError:    logrus.ErrorLevel,
+		BranchInfo:     logrus.InfoLevel,
+		BranchWarn:     logrus.WarnLevel,
+		BranchErrorMsg: logrus.ErrorLevel,
+	}
+)
+
+// String implements fmt.Stringer
+func (b Branch) String() string {
+	if name, ok := branchNames[b]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// WithLevelMapper set the logrus level of every kind of emission, default to Debug for the successful queries,
+// Warn for the slow ones, Error for the failed ones, and the level of the method for Info, Warn and Error,
+// the options adjusting the level of some queries, e.g. WithWarnOnFastErrors, still apply over it, an emission
+// mapped to FatalLevel is logged without exiting, while one mapped to PanicLevel panics in logrus Entry.Log
+func WithLevelMapper(mapper func(branch Branch) logrus.Level) Option {
+	return func(opt *options) {
+		opt.levelMapper = mapper
+	}
+}
+
+// branchLevel return the level of the emissions of branch
+func (l *Logger) branchLevel(branch Branch) logrus.Level {
+	if l.levelMapper != nil {
+		return l.levelMapper(branch)
+	}
+	return defaultBranchLevels[branch]
+}
+
+// WithLoggerRouting emit each kind of emission with the logrus logger returned by router,
+// e.g. to send the successful, slow and failed queries to three different sinks,
+// a nil logger fall back to the primary one, router is called once per Branch by New,
+// WithErrorLogger and WithSlowLogger win over it for their branch whatever the order of the options
+func WithLoggerRouting(router func(branch Branch) *logrus.Logger) Option {
+	return func(opt *options) {
+		opt.loggerRouter = router
+	}
+}
+
+// WithErrorLogger log the failed queries to log instead of the primary logger, as a WithLoggerRouting router
+// returning log for BranchError would, winning over the WithLoggerRouting one whatever the order of the options
+func WithErrorLogger(log *logrus.Logger) Option {
+	return func(opt *options) {
+		opt.errorLogger = log
+	}
+}
+
+// WithSlowLogger log the slow queries to log instead of the primary logger, as a WithLoggerRouting router
+// returning log for BranchSlow would, winning over the WithLoggerRouting one whatever the order of the options,
+// see WithSlowQueryLogger to log them to a second logger, which wins over it with WithSlowQueryLogOnly
+func WithSlowLogger(log *logrus.Logger) Option {
+	return func(opt *options) {
+		opt.slowBranchLogger = log
+	}
+}
+
+// newRoutedBackends return the backends of the branches not emitted by the primary one,
+// built like the primary one but for the logger
+func newRoutedBackends(opt *options) map[Branch]ContextLogger {
+	backends := map[Branch]ContextLogger{}
+	route := func(branch Branch, log *logrus.Logger) {
+		if log != nil {
+			backends[branch] = opt.chainBackend(opt.logrusBackend(log), log)
+		}
+	}
+	if opt.loggerRouter != nil {
+		for branch := range branchNames {
+			route(branch, opt.loggerRouter(branch))
+		}
+	}
+	route(BranchError, opt.errorLogger)
+	route(BranchSlow, opt.slowBranchLogger)
+	if len(backends) == 0 {
+		return nil
+	}
+	return backends
+}
+
+// branchBackend return the backend of the emissions of branch
+func (l *Logger) branchBackend(branch Branch) ContextLogger {
+	if backend, ok := l.routedBackends[branch]; ok {
+		return backend
+	}
+	return l.backend
+}
+
+// LevelMapping the logrus levels of the emissions set by WithLevelMapping, a zero field keeping the
+// default level of its emission, so that PanicLevel can't be mapped
+type LevelMapping struct {
+	Query, SlowQuery, QueryError logrus.Level
+	Info, Warn, Error            logrus.Level
+}
+
+// WithLevelMapping set the logrus levels of the emissions from m, a shorthand for WithLevelMapper,
+// e.g. LevelMapping{Query: logrus.TraceLevel, SlowQuery: logrus.ErrorLevel}, FatalLevel logging without
+// exiting, PanicLevel, which would panic in logrus Entry.Log, being the zero value that keeps the default
+func WithLevelMapping(m LevelMapping) Option {
+	levels := map[Branch]logrus.Level{
+		BranchQuery:    m.Query,
+		BranchSlow:     m.SlowQuery,
+		BranchError:    m.QueryError,
+		BranchInfo:     m.Info,
+		BranchWarn:     m.Warn,
+		BranchErrorMsg: m.Error,
+	}
+	return WithLevelMapper(func(branch Branch) logrus.Level {
+		if level := levels[branch]; level != 0 {
+			return level
+		}
+		return defaultBranchLevels[branch]
+	})
+}
@@ -0,0 +1,230 @@
+package gorm_logrus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newBufferLogger return a logrus logger at Trace writing JSON to a buffer
+func newBufferLogger() (*logrus.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	log := logrus.New()
+	log.SetOutput(buf)
+	log.SetLevel(logrus.TraceLevel)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	return log, buf
+}
+
+func TestErrorAndSlowLoggers(t *testing.T) {
+	primary, primaryBuf := newBufferLogger()
+	errorLog, errorBuf := newBufferLogger()
+	slowLog, slowBuf := newBufferLogger()
+	l := New(WithLogger(primary), WithErrorLogger(errorLog), WithSlowLogger(slowLog), WithSlowThreshold(time.Second))
+
+	ctx := context.Background()
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 'query'", 1 }, nil)
+	l.Trace(ctx, slowBegin(time.Second), func() (string, int64) { return "SELECT 'slow'", 1 }, nil)
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 'error'", 0 }, errors.New("failed"))
+	l.Info(ctx, "info message")
+
+	outputs := map[string]*bytes.Buffer{"primary": primaryBuf, "error": errorBuf, "slow": slowBuf}
+	for event, want := range map[string]string{"'query'": "primary", "'slow'": "slow", "'error'": "error", "info message": "primary"} {
+		for name, buf := range outputs {
+			wantCount := 0
+			if name == want {
+				wantCount = 1
+			}
+			if got := strings.Count(buf.String(), event); got != wantCount {
+				t.Errorf("%s logged %d times to the %s logger, want it once to the %s logger only", event, got, name, want)
+			}
+		}
+	}
+}
+
+func TestRoutedLoggersLevelEnabled(t *testing.T) {
+	primary, primaryBuf := newBufferLogger()
+	slowLog, slowBuf := newBufferLogger()
+	slowLog.SetLevel(logrus.ErrorLevel)
+	l := New(WithLogger(primary), WithSlowLogger(slowLog), WithSlowThreshold(time.Second))
+
+	fc, calls := countingTrace("SELECT 1", 1)
+	l.Trace(context.Background(), slowBegin(time.Second), fc, nil)
+	if primaryBuf.Len() != 0 || slowBuf.Len() != 0 || *calls != 0 {
+		t.Errorf("got %q and %q, fc called %d times, want the slow query skipped by the slow logger level",
+			primaryBuf.String(), slowBuf.String(), *calls)
+	}
+}
+
+func TestRoutedLoggersShareTheBackendChain(t *testing.T) {
+	primary, _ := newBufferLogger()
+	primary.SetFormatter(&logrus.TextFormatter{ForceColors: true})
+	slowLog, slowBuf := newBufferLogger()
+	type ctxKey struct{}
+	l := New(
+		WithEntry(primary.WithField("service", "orders")),
+		WithSlowLogger(slowLog),
+		WithContextLogger(func(ctx context.Context) *logrus.Entry {
+			entry, _ := ctx.Value(ctxKey{}).(*logrus.Entry)
+			return entry
+		}),
+		WithConfig(logger.Config{SlowThreshold: time.Second, Colorful: true}),
+	)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, logrus.WithField("request_id", "r1"))
+	l.Trace(ctx, slowBegin(time.Second), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	out := slowBuf.String()
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("got %q, want no color escapes in the JSON slow logger", out)
+	}
+	if !strings.Contains(out, `"request_id":"r1"`) {
+		t.Errorf("got %q, want the fields of the request scoped entry", out)
+	}
+
+	l.Trace(context.Background(), slowBegin(time.Second), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	if !strings.Contains(slowBuf.String(), `"service":"orders"`) {
+		t.Errorf("got %q, want the fields of the WithEntry entry", slowBuf.String())
+	}
+}
+
+func TestLoggerRoutingFallsBackToPrimary(t *testing.T) {
+	primary, primaryBuf := newBufferLogger()
+	queryLog, queryBuf := newBufferLogger()
+	l := New(WithLogger(primary), WithLoggerRouting(func(branch Branch) *logrus.Logger {
+		if branch == BranchQuery {
+			return queryLog
+		}
+		return nil
+	}))
+
+	l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	l.Warn(context.Background(), "warn message")
+	if !strings.Contains(queryBuf.String(), "SELECT 1") || strings.Contains(queryBuf.String(), "warn message") {
+		t.Errorf("got %q in the query logger, want the query only", queryBuf.String())
+	}
+	if !strings.Contains(primaryBuf.String(), "warn message") || strings.Contains(primaryBuf.String(), "SELECT 1") {
+		t.Errorf("got %q in the primary logger, want the warning only", primaryBuf.String())
+	}
+}
+
+func TestSlowAndErrorLoggersPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		// only sets WithSlowQueryLogOnly, routing first puts WithLoggerRouting after the other options
+		only, routingFirst bool
+		// the loggers of the slow and failed queries
+		slow  []string
+		error string
+	}{
+		{name: "routing last", slow: []string{"slowLogger", "slowQueryLogger"}, error: "errorLogger"},
+		{name: "routing first", routingFirst: true, slow: []string{"slowLogger", "slowQueryLogger"}, error: "errorLogger"},
+		{name: "slow query log only", only: true, slow: []string{"slowQueryLogger"}, error: "errorLogger"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffers := map[string]*bytes.Buffer{}
+			logger := func(name string) *logrus.Logger {
+				log, buf := newBufferLogger()
+				buffers[name] = buf
+				return log
+			}
+			routed := logger("routed")
+			routing := WithLoggerRouting(func(branch Branch) *logrus.Logger {
+				if branch == BranchSlow || branch == BranchError {
+					return routed
+				}
+				return nil
+			})
+			opts := []Option{
+				WithLogger(logger("primary")),
+				WithSlowLogger(logger("slowLogger")),
+				WithErrorLogger(logger("errorLogger")),
+				WithSlowQueryLogger(logger("slowQueryLogger")),
+				WithSlowQueryLogOnly(tt.only),
+				WithSlowThreshold(time.Second),
+			}
+			if tt.routingFirst {
+				opts = append([]Option{routing}, opts...)
+			} else {
+				opts = append(opts, routing)
+			}
+			l := New(opts...)
+
+			l.Trace(context.Background(), slowBegin(time.Second), func() (string, int64) { return "SELECT 'slow'", 1 }, nil)
+			l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 'error'", 0 }, errors.New("failed"))
+			for event, want := range map[string][]string{"'slow'": tt.slow, "'error'": {tt.error}} {
+				for name, buf := range buffers {
+					wantCount := 0
+					for _, w := range want {
+						if name == w {
+							wantCount = 1
+						}
+					}
+					if got := strings.Count(buf.String(), event); got != wantCount {
+						t.Errorf("%s logged %d times to the %s logger, want it to %v only", event, got, name, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLevelMapping(t *testing.T) {
+	emissions := map[string]func(l *Logger){
+		"query": func(l *Logger) {
+			l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+		},
+		"slow query": func(l *Logger) {
+			l.Trace(context.Background(), slowBegin(time.Second), func() (string, int64) { return "SELECT 1", 1 }, nil)
+		},
+		"query error": func(l *Logger) {
+			l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 0 }, errors.New("failed"))
+		},
+		"info":  func(l *Logger) { l.Info(context.Background(), "info message") },
+		"warn":  func(l *Logger) { l.Warn(context.Background(), "warn message") },
+		"error": func(l *Logger) { l.Error(context.Background(), "error message") },
+	}
+	custom := LevelMapping{
+		Query:      logrus.TraceLevel,
+		SlowQuery:  logrus.ErrorLevel,
+		QueryError: logrus.FatalLevel,
+		Info:       logrus.DebugLevel,
+		Warn:       logrus.InfoLevel,
+		Error:      logrus.WarnLevel,
+	}
+	tests := []struct {
+		emission string
+		mapping  LevelMapping
+		want     logrus.Level
+	}{
+		{emission: "query", want: logrus.DebugLevel},
+		{emission: "slow query", want: logrus.WarnLevel},
+		{emission: "query error", want: logrus.ErrorLevel},
+		{emission: "info", want: logrus.InfoLevel},
+		{emission: "warn", want: logrus.WarnLevel},
+		{emission: "error", want: logrus.ErrorLevel},
+		{emission: "query", mapping: custom, want: logrus.TraceLevel},
+		{emission: "slow query", mapping: custom, want: logrus.ErrorLevel},
+		{emission: "query error", mapping: custom, want: logrus.FatalLevel},
+		{emission: "info", mapping: custom, want: logrus.DebugLevel},
+		{emission: "warn", mapping: custom, want: logrus.InfoLevel},
+		{emission: "error", mapping: custom, want: logrus.WarnLevel},
+		{emission: "query", mapping: LevelMapping{SlowQuery: logrus.ErrorLevel}, want: logrus.DebugLevel},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %+v", tt.emission, tt.mapping), func(t *testing.T) {
+			// Entry.Log doesn't exit at FatalLevel, only the Fatal methods do
+			l, hook := newTestLogger(WithLevelMapping(tt.mapping), WithSlowThreshold(time.Second))
+			emissions[tt.emission](l)
+			if entry := hook.LastEntry(); entry == nil || entry.Level != tt.want {
+				t.Errorf("got %v, want the %s logged at %s", entry, tt.emission, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package gorm_logrus
+
+import (
+	"gorm.io/gorm/utils"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+var (
+	gormSourceDir    = sourceDir(reflect.ValueOf(utils.FileWithLineNum).Pointer(), 2)
+	packageSourceDir string
+)
+
+func init() {
+	_, file, _, _ := runtime.Caller(0)
+	packageSourceDir = filepath.Dir(file) + "/"
+}
+
+// sourceDir return the directory depth levels above the file of the func at pc
+func sourceDir(pc uintptr, depth int) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+	dir, _ := fn.FileLine(pc)
+	for i := 0; i < depth; i++ {
+		dir = filepath.Dir(dir)
+	}
+	return dir + "/"
+}
+
+// WithCallerResolver set the func returning the file field, e.g. "path/to/file.go:42",
+// instead of the first caller outside of gorm, as resolved by utils.FileWithLineNum
+func WithCallerResolver(resolver func() string) Option {
+	return func(opt *options) {
+		opt.callerResolver = resolver
+	}
+}
+
+// WithCallerSkipPackages skip the frames whose file path contains one of pkgs as well when resolving
+// the caller, e.g. the generic repository layer wrapping gorm
+func WithCallerSkipPackages(pkgs ...string) Option {
+	return func(opt *options) {
+		opt.callerSkips = append(opt.callerSkips, pkgs...)
+	}
+}
+
+// WithoutCaller omit the file field, saving the stack walk
+func WithoutCaller() Option {
+	return func(opt *options) {
+		opt.noCaller = true
+	}
+}
+
+// gormCaller report whether the caller of t is resolved by utils.FileWithLineNum, as gorm's logger does,
+// from Trace as it must be called by the logger method gorm calls, see traceCall.file
+func (l *Logger) gormCaller(t *traceCall) bool {
+	return !l.noCaller && l.callerResolver == nil && len(l.callerSkips) == 0 && (t.err != nil || t.elapsed >= l.callerMinElapsed)
+}
+
+// caller return the file and line of the caller of gorm
+func (l *Logger) caller(t *traceCall) string {
+	switch {
+	case l.callerResolver != nil:
+		return l.callerResolver()
+	case len(l.callerSkips) > 0:
+		return fileWithLineNum(l.callerSkips)
+	}
+	return t.file
+}
+
+// fileWithLineNum return the file and line of the first caller outside of gorm, this package and skips,
+// like utils.FileWithLineNum but callable from anywhere in this package, used with WithCallerSkipPackages
+func fileWithLineNum(skips []string) string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if isCallerFrame(frame.File) && !skippedFrame(frame.File, skips) {
+			return frame.File + ":" + strconv.FormatInt(int64(frame.Line), 10)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func isCallerFrame(file string) bool {
+	if strings.HasSuffix(file, "_test.go") {
+		return true
+	}
+	return !strings.HasPrefix(file, gormSourceDir) && !strings.HasPrefix(file, packageSourceDir)
+}
+
+func skippedFrame(file string, skips []string) bool {
+	for _, skip := range skips {
+		if strings.Contains(file, skip) {
+			return true
+		}
+	}
+	return false
+}
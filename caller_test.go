@@ -0,0 +1,87 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/taotao2tingbao/gorm-logrus/testdata/repository"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// line return the file and line of its caller, offset by delta lines
+func line(delta int) string {
+	_, file, line, _ := runtime.Caller(1)
+	return file + ":" + strconv.Itoa(line+delta)
+}
+
+func TestCallerDefaultsToGormFileWithLineNum(t *testing.T) {
+	l, hook := newTestLogger()
+	db, mock := openTestDB(t, l, false)
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	want := line(1)
+	db.Exec("UPDATE users SET name = 'x'")
+	if got := hook.LastEntry().Data["file"]; got != want {
+		t.Errorf("got file %v, want the test file %s", got, want)
+	}
+
+	if err := repository.Exec(db, "UPDATE users SET name = 'y'"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := hook.LastEntry().Data["file"].(string); !strings.Contains(got, "testdata/repository/repository.go:") {
+		t.Errorf("got file %v, want the helper package", got)
+	}
+}
+
+func TestCallerSkipPackages(t *testing.T) {
+	l, hook := newTestLogger(WithCallerSkipPackages("testdata/repository"))
+	db, mock := openTestDB(t, l, false)
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	want := line(1)
+	if err := repository.Exec(db, "UPDATE users SET name = 'x'"); err != nil {
+		t.Fatal(err)
+	}
+	if got := hook.LastEntry().Data["file"]; got != want {
+		t.Errorf("got file %v, want the caller of the helper package %s", got, want)
+	}
+}
+
+func TestCallerResolverAndWithoutCaller(t *testing.T) {
+	l, hook := newTestLogger(WithCallerResolver(func() string { return "resolved.go:1" }))
+	fc, _ := countingTrace("SELECT 1", 1)
+	l.Trace(context.Background(), time.Now(), fc, nil)
+	if got := hook.LastEntry().Data["file"]; got != "resolved.go:1" {
+		t.Errorf("got file %v, want the resolved one", got)
+	}
+
+	l, hook = newTestLogger(WithoutCaller())
+	l.Trace(context.Background(), time.Now(), fc, nil)
+	if _, ok := hook.LastEntry().Data["file"]; ok {
+		t.Error("got the file field, want it omitted")
+	}
+}
+
+func TestUnknownCallerOmitted(t *testing.T) {
+	for _, opt := range []Option{WithCallerResolver(func() string { return "" }), WithCallerMinElapsed(time.Hour)} {
+		l, hook := newTestLogger(opt, WithNPlusOneDetection(1))
+		ctx := WithRequestSummary(context.Background())
+		fc, _ := countingTrace("SELECT * FROM users WHERE id = 1", 1)
+		l.Trace(ctx, time.Now(), fc, nil)
+		l.Trace(ctx, time.Now(), fc, nil)
+		warned := false
+		for _, entry := range hook.AllEntries() {
+			warned = warned || entry.Message == "possible N+1 query"
+			if _, ok := entry.Data["file"]; ok {
+				t.Errorf("got %q with the file field %q, want it omitted when the caller is unknown", entry.Message, entry.Data["file"])
+			}
+		}
+		if !warned {
+			t.Error("got no N+1 warning, want one")
+		}
+	}
+}
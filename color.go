@@ -0,0 +1,47 @@
+package gorm_logrus
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+	"os"
+)
+
+// Colored trace formats, after the gorm logger ones, the slow queries having their duration in red
+const (
+	colorTraceFormat     = logger.Yellow + "[%.3fms] " + logger.BlueBold + "[rows:%v]" + logger.Reset + " %s"
+	colorTraceWarnFormat = logger.RedBold + "[%.3fms] " + logger.Yellow + "[rows:%v]" + logger.Magenta + " %s" + logger.Reset
+	colorTraceErrFormat  = logger.RedBold + "[%.3fms] " + logger.BlueBold + "[rows:%v]" + logger.Reset + " %s"
+)
+
+// colorful report whether the traced queries are colored: Colorful is set and log is
+// a text logger writing to a terminal, or forcing the colors
+func colorful(cfg logger.Config, log *logrus.Logger) bool {
+	if !cfg.Colorful || log == nil {
+		return false
+	}
+	formatter, ok := log.Formatter.(*logrus.TextFormatter)
+	if !ok || formatter.DisableColors {
+		return false
+	}
+	if formatter.ForceColors {
+		return true
+	}
+	file, ok := log.Out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorTrace return the colored message of a traced query, red for the failed and slow ones
+func colorTrace(fields logrus.Fields, elapsed float64, rows interface{}, sql string) string {
+	format := colorTraceFormat
+	if _, ok := fields[logrus.ErrorKey]; ok {
+		format = colorTraceErrFormat
+	} else if _, ok := fields["slowLog"]; ok {
+		format = colorTraceWarnFormat
+	}
+	return fmt.Sprintf(format, elapsed, rows, sql)
+}
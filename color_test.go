@@ -0,0 +1,51 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"gorm.io/gorm/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColorful(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter logrus.Formatter
+		colorful  bool
+		colored   bool
+	}{
+		{name: "text forcing colors", formatter: &logrus.TextFormatter{ForceColors: true}, colorful: true, colored: true},
+		{name: "text not a terminal", formatter: &logrus.TextFormatter{}, colorful: true},
+		{name: "text colors disabled", formatter: &logrus.TextFormatter{ForceColors: true, DisableColors: true}, colorful: true},
+		{name: "json", formatter: &logrus.JSONFormatter{}, colorful: true},
+		{name: "not colorful", formatter: &logrus.TextFormatter{ForceColors: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newBufferLogger()
+			log.SetFormatter(tt.formatter)
+			l := New(WithLogger(log), WithConfig(logger.Config{SlowThreshold: time.Second, Colorful: tt.colorful}))
+			l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+			if colored := strings.Contains(buf.String(), logger.Yellow+"["); colored != tt.colored {
+				t.Errorf("got %q, want the yellow duration %v", buf.String(), tt.colored)
+			}
+		})
+	}
+}
+
+func TestColorfulSlowAndErrorInRed(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	log.SetFormatter(&logrus.TextFormatter{ForceColors: true})
+	l := New(WithLogger(log), WithConfig(logger.Config{SlowThreshold: time.Second, Colorful: true}))
+	l.Trace(context.Background(), slowBegin(time.Second), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 2", 0 }, errors.New("failed"))
+	for _, entry := range hook.AllEntries() {
+		if !strings.HasPrefix(entry.Message, logger.RedBold+"[") {
+			t.Errorf("got message %q, want the duration in red", entry.Message)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+	"time"
+)
+
+type (
+	CallOption func(opt *callOptions)
+	// ContextExtractor return the fields to log from the context of a call, e.g. its request id
+	ContextExtractor func(ctx context.Context) logrus.Fields
+	callOptions      struct {
+		slowThreshold *time.Duration
+		logLevel      logger.LogLevel
+	}
+	callOptionsKey struct{}
+	fieldsKey      struct{}
+)
+
+// CallSlowThreshold override the slow threshold for a single call
+func CallSlowThreshold(threshold time.Duration) CallOption {
+	return func(opt *callOptions) {
+		opt.slowThreshold = &threshold
+	}
+}
+
+// CallLogLevel override the log level for a single call
+func CallLogLevel(level logger.LogLevel) CallOption {
+	return func(opt *callOptions) {
+		opt.logLevel = level
+	}
+}
+
+// WithCallOptions return a copy of ctx carrying the call options,
+// options already stored in ctx are kept unless overridden
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	var opt callOptions
+	if prev, ok := ctx.Value(callOptionsKey{}).(*callOptions); ok {
+		opt = *prev
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	return context.WithValue(ctx, callOptionsKey{}, &opt)
+}
+
+func callOptionsFrom(ctx context.Context) *callOptions {
+	if ctx == nil {
+		return nil
+	}
+	opt, _ := ctx.Value(callOptionsKey{}).(*callOptions)
+	return opt
+}
+
+// WithFields return a copy of ctx carrying fields logged with every entry of the calls using it,
+// merged over the fields already stored in ctx, computed fields such as file win on conflicts
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	prev := contextFields(ctx)
+	merged := make(logrus.Fields, len(prev)+len(fields))
+	for k, v := range prev {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+func contextFields(ctx context.Context) logrus.Fields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(logrus.Fields)
+	return fields
+}
+
+// WithContextFields log the fields returned by the extractors from the context of every Info, Warn, Error
+// and Trace call, e.g. the request and user ids stored by a middleware, a nil return adding nothing,
+// additive: each call appends to the extractors already registered, their fields are merged in
+// registration order, the last one winning on conflicts, and the WithFields ones win over them
+func WithContextFields(extractors ...ContextExtractor) Option {
+	return func(opt *options) {
+		opt.contextExtractors = append(opt.contextExtractors, extractors...)
+	}
+}
+
+// extractedFields return the merged fields of the context extractors, none for the empty contexts
+func (l *Logger) extractedFields(ctx context.Context) logrus.Fields {
+	if len(l.contextExtractors) == 0 || ctx == nil || ctx == context.Background() || ctx == context.TODO() {
+		return nil
+	}
+	layers := make([]logrus.Fields, 0, len(l.contextExtractors))
+	for _, extract := range l.contextExtractors {
+		layers = append(layers, extract(ctx))
+	}
+	return mergeFields(layers...)
+}
@@ -0,0 +1,60 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"testing"
+	"time"
+)
+
+type requestIDKey struct{}
+
+func requestIDFields(ctx context.Context) logrus.Fields {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	if !ok {
+		return nil
+	}
+	return logrus.Fields{"request_id": id, "source": "request"}
+}
+
+func TestContextFields(t *testing.T) {
+	l, hook := newTestLogger(WithContextFields(requestIDFields), WithContextFields(func(context.Context) logrus.Fields {
+		return logrus.Fields{"source": "last"}
+	}))
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "r1")
+	l.Info(ctx, "info message")
+	l.Warn(ctx, "warn message")
+	l.Error(ctx, "error message")
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 0 }, errors.New("failed"))
+	for _, entry := range hook.AllEntries() {
+		if entry.Data["request_id"] != "r1" || entry.Data["source"] != "last" {
+			t.Errorf("got %q fields %v, want the request_id field and the last extractor winning", entry.Message, entry.Data)
+		}
+	}
+
+	hook.Reset()
+	l.Info(WithFields(ctx, logrus.Fields{"source": "call"}), "info message")
+	if got := hook.LastEntry().Data["source"]; got != "call" {
+		t.Errorf("got source %v, want the WithFields field winning over the extractors", got)
+	}
+}
+
+func TestContextFieldsEmptyContext(t *testing.T) {
+	calls := 0
+	l, hook := newTestLogger(WithContextFields(func(ctx context.Context) logrus.Fields {
+		calls++
+		return requestIDFields(ctx)
+	}))
+	l.Info(context.Background(), "background")
+	l.Info(context.WithValue(context.Background(), struct{}{}, 1), "no request id")
+	if calls != 1 {
+		t.Errorf("extractor called %d times, want it skipped for the background context", calls)
+	}
+	for _, entry := range hook.AllEntries() {
+		if len(entry.Data) != 0 {
+			t.Errorf("got %q fields %v, want none", entry.Message, entry.Data)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package gorm_logrus
+
+import (
+	"gorm.io/gorm/logger"
+	"sync"
+)
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger logger.Interface
+)
+
+// SetDefault configure the shared logger returned by Default
+func SetDefault(opts ...Option) {
+	l := New(opts...)
+	defaultMu.Lock()
+	defaultLogger = l
+	defaultMu.Unlock()
+}
+
+// Default return the shared logger configured by SetDefault,
+// a logger with the standard logrus logger if SetDefault was never called
+func Default() logger.Interface {
+	defaultMu.RLock()
+	l := defaultLogger
+	defaultMu.RUnlock()
+	if l != nil {
+		return l
+	}
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultLogger == nil {
+		defaultLogger = New()
+	}
+	return defaultLogger
+}
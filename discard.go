@@ -0,0 +1,13 @@
+package gorm_logrus
+
+import (
+	"gorm.io/gorm/logger"
+)
+
+// Discard return a Logger doing nothing at all, not even calling the fc of Trace,
+// cheaper than a Silent LogMode for benchmark baselines and hot paths
+func Discard() logger.Interface {
+	l := New().(*Logger)
+	l.discard = true
+	return l
+}
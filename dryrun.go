@@ -0,0 +1,30 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+)
+
+// CaptureSQL run fn on a DryRun session of db and return the sql it generates, without executing it,
+// transformed like in the logs when db's Logger is one of ours, e.g. redacted or fingerprinted
+//
+//	sql, err := gorm_logrus.CaptureSQL(ctx, db, func(tx *gorm.DB) *gorm.DB {
+//		return tx.Where("name = ?", "jinzhu").First(&user)
+//	})
+func CaptureSQL(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) *gorm.DB) (string, error) {
+	tx := fn(db.Session(&gorm.Session{DryRun: true, Context: ctx}))
+	if tx == nil {
+		return "", errors.New("gorm logger: CaptureSQL func returned a nil *gorm.DB")
+	}
+	if tx.Error != nil {
+		return "", tx.Error
+	}
+	stmt := tx.Statement
+	l, ok := db.Logger.(*Logger)
+	if !ok {
+		return tx.Dialector.Explain(stmt.SQL.String(), stmt.Vars...), nil
+	}
+	sql, vars := l.ParamsFilter(ctx, stmt.SQL.String(), stmt.Vars...)
+	return l.formatSQL(tx.Dialector.Explain(sql, vars...)), nil
+}
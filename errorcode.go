@@ -0,0 +1,46 @@
+package gorm_logrus
+
+import (
+	"errors"
+)
+
+// ErrorCodeExtractor return the driver error code of err, ok false when err isn't one of its driver,
+// see the mysqlerr and pgerr sub-packages
+type ErrorCodeExtractor func(err error) (code string, ok bool)
+
+// WithErrorCodeField log the driver error code of query errors as the error_code field,
+// by default only errors exposing a SQLState() string method are recognized, such as pgx and pq ones
+func WithErrorCodeField(enabled bool) Option {
+	return func(opt *options) {
+		opt.errorCodeField = enabled
+	}
+}
+
+// WithErrorCodeExtractors add error code extractors, tried in order before the SQLState one,
+// enabling the error_code field
+func WithErrorCodeExtractors(extractors ...ErrorCodeExtractor) Option {
+	return func(opt *options) {
+		opt.errorCodeField = true
+		opt.errorCodeExtractors = append(opt.errorCodeExtractors, extractors...)
+	}
+}
+
+// SQLStateCode the default extractor, return the SQLState of errors implementing it
+func SQLStateCode(err error) (string, bool) {
+	var sqlState interface{ SQLState() string }
+	if errors.As(err, &sqlState) {
+		if code := sqlState.SQLState(); code != "" {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+func (l *Logger) errorCode(err error) (string, bool) {
+	for _, extract := range l.errorCodeExtractors {
+		if code, ok := extract(err); ok {
+			return code, true
+		}
+	}
+	return SQLStateCode(err)
+}
@@ -0,0 +1,48 @@
+package gorm_logrus
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+)
+
+// errorLevel the level of the failed queries whose error matches err, see WithErrorLevel
+type errorLevel struct {
+	err   error
+	level logrus.Level
+}
+
+// WithIgnoreErrors trace the queries failing with one of errs, matched with errors.Is, like successful ones,
+// as done for gorm.ErrRecordNotFound by IgnoreRecordNotFoundError, e.g. context.Canceled
+func WithIgnoreErrors(errs ...error) Option {
+	return func(opt *options) {
+		opt.ignoredErrors = append(opt.ignoredErrors, errs...)
+	}
+}
+
+// WithErrorLevel log the queries failing with err, matched with errors.Is, at level instead of Error,
+// the first matching mapping wins, e.g. Warn for the unique violations handled by the application
+func WithErrorLevel(err error, level logrus.Level) Option {
+	return func(opt *options) {
+		opt.errorLevels = append(opt.errorLevels, errorLevel{err: err, level: level})
+	}
+}
+
+// ignoredError report whether err matches one of the ignored errors
+func (l *Logger) ignoredError(err error) bool {
+	for _, target := range l.ignoredErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// mappedErrorLevel return the level mapped to err by WithErrorLevel, if any
+func (l *Logger) mappedErrorLevel(err error) (logrus.Level, bool) {
+	for _, mapping := range l.errorLevels {
+		if errors.Is(err, mapping.err) {
+			return mapping.level, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,44 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"testing"
+	"time"
+)
+
+var errUniqueViolation = errors.New("unique violation")
+
+func TestIgnoreErrorsAndErrorLevels(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		level logrus.Level
+		error bool
+	}{
+		{name: "ignored", err: context.Canceled, level: logrus.DebugLevel},
+		{name: "wrapped ignored", err: fmt.Errorf("query: %w", context.Canceled), level: logrus.DebugLevel},
+		{name: "mapped", err: errUniqueViolation, level: logrus.WarnLevel, error: true},
+		{name: "wrapped mapped", err: fmt.Errorf("insert: %w", errUniqueViolation), level: logrus.WarnLevel, error: true},
+		{name: "other", err: errors.New("failed"), level: logrus.ErrorLevel, error: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, hook := newTestLogger(
+				WithIgnoreErrors(context.Canceled),
+				WithErrorLevel(errUniqueViolation, logrus.WarnLevel),
+				WithErrorLevel(errUniqueViolation, logrus.InfoLevel),
+			)
+			l.Trace(context.Background(), time.Now(), func() (string, int64) { return "INSERT INTO t VALUES (1)", 0 }, tt.err)
+			entry := hook.LastEntry()
+			if entry == nil || entry.Level != tt.level {
+				t.Fatalf("got %v, want the query logged at %s", entry, tt.level)
+			}
+			if _, ok := entry.Data[logrus.ErrorKey]; ok != tt.error {
+				t.Errorf("got fields %v, want the error field %v", entry.Data, tt.error)
+			}
+		})
+	}
+}
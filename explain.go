@@ -0,0 +1,255 @@
+package gorm_logrus
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	explainTimeout   = 500 * time.Millisecond
+	explainPerMinute = 10
+	explainQueueSize = 16
+)
+
+type (
+	// ExplainOption configure WithExplainOnSlow
+	ExplainOption  func(opt *explainOptions)
+	explainOptions struct {
+		dialect ExplainDialect
+		timeout time.Duration
+		limiter *windowLimiter
+	}
+	// ExplainDialect the EXPLAIN syntax used by WithExplainOnSlow
+	ExplainDialect int
+)
+
+const (
+	// ExplainAuto detect the dialect from the identifier quotes and placeholders of the sql
+	ExplainAuto ExplainDialect = iota
+	// ExplainMySQL run EXPLAIN <sql>
+	ExplainMySQL
+	// ExplainPostgres run EXPLAIN (FORMAT TEXT) <sql>
+	ExplainPostgres
+)
+
+// WithExplainDialect set the EXPLAIN syntax, default ExplainAuto
+func WithExplainDialect(dialect ExplainDialect) ExplainOption {
+	return func(opt *explainOptions) {
+		opt.dialect = dialect
+	}
+}
+
+// WithExplainTimeout set the timeout of each EXPLAIN, default 500ms
+func WithExplainTimeout(timeout time.Duration) ExplainOption {
+	return func(opt *explainOptions) {
+		opt.timeout = timeout
+	}
+}
+
+// WithExplainRateLimit run at most n EXPLAIN per minute, default 10
+func WithExplainRateLimit(n int) ExplainOption {
+	return func(opt *explainOptions) {
+		opt.limiter = newWindowLimiter(n, time.Minute)
+	}
+}
+
+// WithAutoExplain run EXPLAIN on db for the slow queries with the default ExplainOption,
+// an alias of WithExplainOnSlow(db)
+func WithAutoExplain(db *sql.DB) Option {
+	return WithExplainOnSlow(db)
+}
+
+// WithExplainOnSlow run EXPLAIN on db for the slow SELECT, UPDATE and DELETE statements, which EXPLAIN
+// plans without executing them, and log the plan with the sql as the plan field of a separate entry at the
+// level of the slow query, both entries having the same query_id field to join them, db is queried directly
+// so it is never traced, EXPLAIN errors are logged at Debug
+//
+// The plan isn't a field of the slow query entry as EXPLAIN runs from a background goroutine, off the
+// query path, until Stop is called, a slow query being dropped when the goroutine is busy. As the sql
+// interpolated by gorm isn't safe to execute, the sql holding more than one statement, e.g. a bound value
+// closing its string literal early, is never explained, nor the sql of WithParameterizedQueries, having no
+// values to plan with
+func WithExplainOnSlow(db *sql.DB, opts ...ExplainOption) Option {
+	return func(opt *options) {
+		// built by New, the loggers sharing the option don't share the rate limit
+		eo := &explainOptions{timeout: explainTimeout}
+		for _, o := range opts {
+			o(eo)
+		}
+		if eo.limiter == nil {
+			eo.limiter = newWindowLimiter(explainPerMinute, time.Minute)
+		}
+		opt.explainDB = db
+		opt.explainOpts = eo
+	}
+}
+
+type (
+	// explainer run the EXPLAIN of the slow queries from a goroutine
+	explainer struct {
+		db   *sql.DB
+		opts *explainOptions
+		jobs chan explainJob
+		done chan struct{}
+		once sync.Once
+	}
+	explainJob struct {
+		ctx     context.Context
+		backend ContextLogger
+		level   logrus.Level
+		// sql the sql to explain, logged the logged one
+		sql, logged string
+		// queryID the query_id field of the slow query entry
+		queryID interface{}
+		// flushed closed once the jobs queued before it are done, set for the Flush markers only
+		flushed chan struct{}
+	}
+)
+
+func newExplainer(db *sql.DB, opts *explainOptions, l *Logger) *explainer {
+	if db == nil {
+		return nil
+	}
+	e := &explainer{db: db, opts: opts, jobs: make(chan explainJob, explainQueueSize), done: make(chan struct{})}
+	go func() {
+		for {
+			select {
+			case job := <-e.jobs:
+				e.run(l, job)
+			case <-e.done:
+				return
+			}
+		}
+	}()
+	return e
+}
+
+// accept report whether the slow query sql is explained, it isn't when it isn't explainable
+// or the rate limit is reached, see enqueue
+func (e *explainer) accept(l *Logger, sql string) bool {
+	if e == nil || l.parameterized() || !explainable(sql) {
+		return false
+	}
+	allowed, _ := e.opts.limiter.allow()
+	return allowed
+}
+
+// enqueue queue the EXPLAIN of an accepted slow query, unless the queue is full
+func (e *explainer) enqueue(job explainJob) {
+	select {
+	case e.jobs <- job:
+	default:
+	}
+}
+
+func (e *explainer) run(l *Logger, job explainJob) {
+	if job.flushed != nil {
+		close(job.flushed)
+		return
+	}
+	plan, err := e.explain(job.sql)
+	switch {
+	case err != nil:
+		l.logTo(job.backend, job.ctx, logrus.DebugLevel, logrus.Fields{logrus.ErrorKey: err, "sql": job.logged, "query_id": job.queryID}, "gorm logger explain failed")
+	case plan != "":
+		l.logTo(job.backend, job.ctx, job.level, logrus.Fields{"sql": job.logged, "plan": plan, "query_id": job.queryID}, "sql explain plan")
+	}
+}
+
+// flush wait until the jobs queued so far are done, or ctx is done
+func (e *explainer) flush(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case e.jobs <- explainJob{flushed: flushed}:
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stop stop the explain goroutine, the queued jobs are dropped
+func (e *explainer) stop() {
+	if e != nil {
+		e.once.Do(func() { close(e.done) })
+	}
+}
+
+// explainable report whether query can be explained: a single SELECT, UPDATE or DELETE statement
+func explainable(query string) bool {
+	switch statementVerb(query) {
+	case "SELECT", "UPDATE", "DELETE":
+	default:
+		return false
+	}
+	// whatever the quoting rules of the database, a second statement needs a separator
+	return !strings.Contains(strings.TrimRight(query, "; \t\r\n"), ";")
+}
+
+// explainPrefix return the EXPLAIN statement prefix of query
+func (e *explainer) explainPrefix(query string) string {
+	dialect := e.opts.dialect
+	if dialect == ExplainAuto {
+		dialect = ExplainMySQL
+		if !strings.Contains(query, "`") && (strings.Contains(query, `"`) || strings.Contains(query, "$1")) {
+			dialect = ExplainPostgres
+		}
+	}
+	if dialect == ExplainPostgres {
+		return "EXPLAIN (FORMAT TEXT) "
+	}
+	return "EXPLAIN "
+}
+
+// explain return the plan of query
+func (e *explainer) explain(query string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.opts.timeout)
+	defer cancel()
+	rows, err := e.db.QueryContext(ctx, e.explainPrefix(query)+query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	var (
+		plan   []string
+		values = make([]sql.NullString, len(columns))
+		dest   = make([]interface{}, len(columns))
+	)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "", err
+		}
+		line := make([]string, len(values))
+		for i, v := range values {
+			line[i] = v.String
+		}
+		plan = append(plan, strings.Join(line, "\t"))
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("explain: %w", err)
+	}
+	return strings.Join(plan, "\n"), nil
+}
@@ -0,0 +1,186 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExplainOnSlow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectQuery(regexp.QuoteMeta("EXPLAIN SELECT * FROM `users` WHERE `id` = 1")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "select_type", "table"}).AddRow("1", "SIMPLE", "users"))
+
+	l, hook := newTestLogger(WithSlowThreshold(time.Millisecond), WithExplainOnSlow(db))
+	defer l.Stop()
+	fc, _ := countingTrace("SELECT * FROM `users` WHERE `id` = 1", 1)
+	l.Trace(context.Background(), slowBegin(time.Millisecond), fc, nil)
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := hook.AllEntries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want the slow query and its plan", len(entries))
+	}
+	if _, ok := entries[0].Data["plan"]; ok {
+		t.Error("the slow query entry has the plan field, want it on a separate entry")
+	}
+	plan := entries[1]
+	if plan.Level != logrus.WarnLevel || plan.Data["plan"] != "1\tSIMPLE\tusers" {
+		t.Errorf("got the plan entry %s %v, want Warn with the plan field", plan.Level, plan.Data)
+	}
+	if id := entries[0].Data["query_id"]; id == nil || plan.Data["query_id"] != id {
+		t.Errorf("got the query_id %v and %v, want the same one on the slow query and its plan", id, plan.Data["query_id"])
+	}
+}
+
+func TestExplainOnSlowErrorLoggedAtDebug(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("EXPLAIN").WillReturnError(context.DeadlineExceeded)
+
+	l, hook := newTestLogger(WithSlowThreshold(time.Millisecond), WithExplainOnSlow(db, WithExplainDialect(ExplainPostgres)))
+	defer l.Stop()
+	fc, _ := countingTrace(`DELETE FROM "users" WHERE "id" = 1`, 1)
+	l.Trace(context.Background(), slowBegin(time.Millisecond), fc, nil)
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := hook.AllEntries()
+	if len(entries) != 2 || entries[1].Level != logrus.DebugLevel || entries[1].Data[logrus.ErrorKey] != context.DeadlineExceeded {
+		t.Fatalf("got %d entries, want the slow query and the EXPLAIN error at Debug", len(entries))
+	}
+	if id := entries[0].Data["query_id"]; id == nil || entries[1].Data["query_id"] != id {
+		t.Errorf("got the query_id %v and %v, want the same one on the slow query and its EXPLAIN error", id, entries[1].Data["query_id"])
+	}
+}
+
+func TestExplainOnSlowSkipsUnsafeSQL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	l, hook := newTestLogger(WithSlowThreshold(time.Millisecond), WithExplainOnSlow(db))
+	defer l.Stop()
+	for _, sql := range []string{
+		`SELECT * FROM "users" WHERE "name" = 'x\'; DROP TABLE users; --'`,
+		"INSERT INTO `users` (`name`) VALUES ('x')",
+		"CREATE TABLE `users` (`id` bigint)",
+		"BEGIN",
+	} {
+		fc, _ := countingTrace(sql, 1)
+		l.Trace(context.Background(), slowBegin(time.Millisecond), fc, nil)
+	}
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if len(hook.AllEntries()) != 4 {
+		t.Errorf("got %d entries, want the 4 slow queries only", len(hook.AllEntries()))
+	}
+}
+
+func TestExplainOnSlowRateLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("EXPLAIN").WillReturnRows(sqlmock.NewRows([]string{"plan"}).AddRow("Seq Scan on users"))
+
+	l, hook := newTestLogger(WithSlowThreshold(time.Millisecond), WithExplainOnSlow(db, WithExplainRateLimit(1)))
+	defer l.Stop()
+	for i := 0; i < 3; i++ {
+		fc, _ := countingTrace("SELECT * FROM users", 1)
+		l.Trace(context.Background(), slowBegin(time.Millisecond), fc, nil)
+	}
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	// the 3 slow queries and a single plan, sqlmock failing any EXPLAIN beyond the expected one
+	if len(hook.AllEntries()) != 4 {
+		t.Errorf("got %d entries, want 3 slow queries and 1 plan", len(hook.AllEntries()))
+	}
+	ids := 0
+	for _, e := range hook.AllEntries() {
+		if _, ok := e.Data["query_id"]; ok {
+			ids++
+		}
+	}
+	if ids != 2 {
+		t.Errorf("got %d entries with the query_id field, want the explained slow query and its plan", ids)
+	}
+}
+
+func TestExplainOnSlowRateLimitPerLogger(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("EXPLAIN").WillReturnRows(sqlmock.NewRows([]string{"plan"}).AddRow("Seq Scan on users"))
+	mock.ExpectQuery("EXPLAIN").WillReturnRows(sqlmock.NewRows([]string{"plan"}).AddRow("Seq Scan on users"))
+
+	explain := WithExplainOnSlow(db, WithExplainRateLimit(1))
+	for i := 0; i < 2; i++ {
+		l, hook := newTestLogger(WithSlowThreshold(time.Millisecond), explain)
+		fc, _ := countingTrace("SELECT * FROM users", 1)
+		l.Trace(context.Background(), slowBegin(time.Millisecond), fc, nil)
+		if err := l.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		l.Stop()
+		if len(hook.AllEntries()) != 2 {
+			t.Errorf("logger %d: got %d entries, want the slow query and its plan", i, len(hook.AllEntries()))
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExplainOnSlowQueryLogOnly(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("EXPLAIN").WillReturnRows(sqlmock.NewRows([]string{"plan"}).AddRow("Seq Scan on users"))
+
+	slowLog, slowBuf := newBufferLogger()
+	l, hook := newTestLogger(WithSlowThreshold(time.Millisecond), WithExplainOnSlow(db),
+		WithSlowQueryLogger(slowLog), WithSlowQueryLogOnly(true))
+	defer l.Stop()
+	fc, _ := countingTrace("SELECT * FROM users", 1)
+	l.Trace(context.Background(), slowBegin(time.Millisecond), fc, nil)
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(hook.AllEntries()) != 0 || !strings.Contains(slowBuf.String(), "sql explain plan") {
+		t.Errorf("got %d entries in the primary logger and %q in the slow query one, want the plan with its slow query",
+			len(hook.AllEntries()), slowBuf.String())
+	}
+}
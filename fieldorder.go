@@ -0,0 +1,55 @@
+package gorm_logrus
+
+import (
+	"sort"
+)
+
+// WithFieldOrder set the preferred order of the fields for formatters honoring it, see SortingFunc,
+// the "*" key marks the position of the unlisted fields, which go last otherwise
+//
+//	WithFieldOrder([]string{"time", "level", "msg", "*", "sql"})
+func WithFieldOrder(order []string) Option {
+	return func(opt *options) {
+		opt.fieldOrder = append([]string(nil), order...)
+	}
+}
+
+// FieldOrder return the field order set by WithFieldOrder
+func (l *Logger) FieldOrder() []string {
+	return append([]string(nil), l.fieldOrder...)
+}
+
+// SortingFunc return a func sorting keys by the field order, meant for logrus.TextFormatter.SortingFunc
+func (l *Logger) SortingFunc() func(keys []string) {
+	return SortFields(l.fieldOrder)
+}
+
+// SortFields return a func sorting keys by order, unlisted keys are sorted alphabetically
+// at the position of the "*" key or last
+func SortFields(order []string) func(keys []string) {
+	rank := make(map[string]int, len(order))
+	wildcard := len(order)
+	for i, key := range order {
+		if key == "*" {
+			wildcard = i
+		} else if _, ok := rank[key]; !ok {
+			rank[key] = i
+		}
+	}
+	return func(keys []string) {
+		sort.SliceStable(keys, func(i, j int) bool {
+			ri, iok := rank[keys[i]]
+			rj, jok := rank[keys[j]]
+			if !iok {
+				ri = wildcard
+			}
+			if !jok {
+				rj = wildcard
+			}
+			if ri != rj {
+				return ri < rj
+			}
+			return keys[i] < keys[j]
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// traceFields return the fields shared by all Trace branches
+func (l *Logger) traceFields(t *traceCall) logrus.Fields {
+	fields := logrus.Fields{}
+	if l.resolveCaller(t) {
+		fields = l.callerFields(l.caller(t))
+	}
+	if l.migrationField && isDDL(t.sql()) {
+		fields["migration"] = true
+	}
+	if l.queryKindField {
+		fields["kind"] = queryKind(t.sql())
+	}
+	if l.operationField {
+		fields["operation"] = operationLabel(t.sql())
+	}
+	info := modelFrom(t.ctx)
+	if info.model != "" {
+		fields["model"] = info.model
+	}
+	if info.table == "" && l.tableField {
+		info.table = sqlTable(t.sql())
+	}
+	if info.table != "" {
+		fields["table"] = l.tableLabel(info.table)
+	}
+	if l.batchFields {
+		if batch, ok := batchFrom(t.ctx); ok {
+			fields["batch_size"], fields["batch_index"] = batch.size, batch.index
+		}
+	}
+	if l.attemptKey != nil && t.ctx != nil {
+		if attempt, ok := t.ctx.Value(l.attemptKey).(int); ok {
+			fields["attempt"] = attempt
+		}
+	}
+	if l.deadlineRiskMargin > 0 && t.ctx != nil {
+		if deadline, ok := t.ctx.Deadline(); ok && deadline.Sub(t.begin) < l.deadlineRiskMargin {
+			fields["deadline_risk"] = true
+		}
+	}
+	if l.extractLiterals && l.sqlMode == SQLModeFull {
+		fields["literals"] = l.literals(t.sql())
+	}
+	if l.rowsSemantic {
+		fields["rows_semantic"] = rowsSemantic(t.sql())
+	}
+	if l.structuredFields {
+		fields["slow"] = l.isSlow(t)
+	}
+	if l.queryIDField {
+		fields["query_id"] = newQueryID()
+	}
+	if t.executed > 0 {
+		fields["prepared"], fields["executed"] = t.prepared, t.executed
+	}
+	if l.endTimeField {
+		fields["end_time"] = l.formatTime(t.begin.Add(t.elapsed))
+	}
+	return fields
+}
+
+// WithEndTimeField log the end time of queries as the end_time field
+func WithEndTimeField(enabled bool) Option {
+	return func(opt *options) {
+		opt.endTimeField = enabled
+	}
+}
+
+// WithTimeLocation set the location of the logged times, default to the time's own location
+func WithTimeLocation(loc *time.Location) Option {
+	return func(opt *options) {
+		opt.timeLocation = loc
+	}
+}
+
+// formatTime format t as RFC3339Nano in the configured location
+func (l *Logger) formatTime(t time.Time) string {
+	if l.timeLocation != nil {
+		t = t.In(l.timeLocation)
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// cloneFields return a shallow copy of fields
+func cloneFields(fields logrus.Fields) logrus.Fields {
+	clone := make(logrus.Fields, len(fields))
+	for k, v := range fields {
+		clone[k] = v
+	}
+	return clone
+}
+
+// mergeFields merge the non empty layers, later layers win on conflicts,
+// a single non empty layer is returned as is
+func mergeFields(layers ...logrus.Fields) logrus.Fields {
+	var (
+		merged logrus.Fields
+		n      int
+	)
+	for _, layer := range layers {
+		if len(layer) > 0 {
+			merged = layer
+			n++
+		}
+	}
+	if n <= 1 {
+		return merged
+	}
+	merged = logrus.Fields{}
+	for _, layer := range layers {
+		for k, v := range layer {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// WithAttemptKey log the int stored in the context under key, such as a retry counter, as the attempt field
+func WithAttemptKey(key interface{}) Option {
+	return func(opt *options) {
+		opt.attemptKey = key
+	}
+}
+
+// WithDeadlineRiskMargin tag the queries started with less than margin left before their context
+// deadline with deadline_risk=true
+func WithDeadlineRiskMargin(margin time.Duration) Option {
+	return func(opt *options) {
+		opt.deadlineRiskMargin = margin
+	}
+}
+
+// WithHostField log the hostname, resolved once by New, as the hostname field of every entry
+func WithHostField(enabled bool) Option {
+	return func(opt *options) {
+		opt.hostField = enabled
+	}
+}
+
+// WithPIDField log the process id as the pid field of every entry
+func WithPIDField(enabled bool) Option {
+	return func(opt *options) {
+		opt.pidField = enabled
+	}
+}
+
+// WithStaticFields log fields with every entry, e.g. component=gorm, calling it again merges the fields,
+// the later ones winning, and the per-call fields win over them, see WithFields for the per-context ones
+func WithStaticFields(fields logrus.Fields) Option {
+	return func(opt *options) {
+		if opt.userFields == nil {
+			opt.userFields = make(logrus.Fields, len(fields))
+		}
+		for k, v := range fields {
+			opt.userFields[k] = v
+		}
+	}
+}
+
+func instanceFields(host, pid bool) logrus.Fields {
+	fields := logrus.Fields{}
+	if host {
+		if hostname, err := os.Hostname(); err == nil {
+			fields["hostname"] = hostname
+		}
+	}
+	if pid {
+		fields["pid"] = os.Getpid()
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// WithCallerMinElapsed only resolve the caller of queries taking at least d, and of failed ones,
+// the file field is omitted for the faster ones
+func WithCallerMinElapsed(d time.Duration) Option {
+	return func(opt *options) {
+		opt.callerMinElapsed = d
+	}
+}
+
+// WithCallerMinRows only resolve the caller of the successful fast queries returning or affecting
+// at least n rows, slow and failed queries always get the file field, combined with WithCallerMinElapsed
+// both conditions must be met
+func WithCallerMinRows(n int64) Option {
+	return func(opt *options) {
+		opt.callerMinRows = n
+	}
+}
+
+// resolveCaller report whether the caller of the call should be resolved
+func (l *Logger) resolveCaller(t *traceCall) bool {
+	if l.noCaller {
+		return false
+	}
+	if t.err != nil {
+		return true
+	}
+	if t.elapsed < l.callerMinElapsed {
+		return false
+	}
+	if l.callerMinRows > 0 && !l.isSlow(t) {
+		_, rows := t.result()
+		return rows >= l.callerMinRows
+	}
+	return true
+}
+
+// callerFields return the fields describing the caller file, none when it is unknown,
+// falling back to the combined file field when it can't be split
+func (l *Logger) callerFields(file string) logrus.Fields {
+	if file == "" {
+		return logrus.Fields{}
+	}
+	if l.splitCaller {
+		if i := strings.LastIndexByte(file, ':'); i > 0 {
+			if line, err := strconv.Atoi(file[i+1:]); err == nil {
+				return logrus.Fields{
+					"caller_file": file[:i],
+					"caller_line": line,
+				}
+			}
+		}
+	}
+	return logrus.Fields{"file": file}
+}
+
+// RowsSentinel how the unknown rows count, -1, is rendered in the rows field
+type RowsSentinel int
+
+const (
+	// RowsSentinelKeep render it as "-"
+	RowsSentinelKeep RowsSentinel = iota
+	// RowsSentinelOmit omit the rows field
+	RowsSentinelOmit
+	// RowsSentinelBoolean omit the rows field and add a has_rows field, false when unknown
+	RowsSentinelBoolean
+)
+
+// WithRowsSentinel set how the unknown rows count is rendered in the rows field, default RowsSentinelKeep
+func WithRowsSentinel(mode RowsSentinel) Option {
+	return func(opt *options) {
+		opt.rowsSentinel = mode
+	}
+}
+
+// WithRowsSemantic tag the rows field with the rows_semantic field, returned for the reads and
+// affected for the other queries, from the leading keyword of the sql, see WithQueryKindField
+func WithRowsSemantic(enabled bool) Option {
+	return func(opt *options) {
+		opt.rowsSemantic = enabled
+	}
+}
+
+// rowsSemantic return whether the rows of sql are returned or affected ones
+func rowsSemantic(sql string) string {
+	if queryKind(sql) == QueryKindRead {
+		return "returned"
+	}
+	return "affected"
+}
+
+// rowsFields set the rows fields of rows
+func (l *Logger) rowsFields(fields logrus.Fields, rows int64) {
+	switch {
+	case l.rowsSentinel == RowsSentinelBoolean:
+		fields["has_rows"] = rows != -1
+		if rows != -1 {
+			fields["rows"] = rows
+		}
+	case rows != -1:
+		fields["rows"] = rows
+	case l.rowsSentinel == RowsSentinelKeep:
+		fields["rows"] = rowsValue(rows)
+	}
+}
+
+func elapsedMs(elapsed time.Duration) float64 {
+	return float64(elapsed.Nanoseconds()) / 1e6
+}
+
+func rowsValue(rows int64) interface{} {
+	if rows == -1 {
+		return "-"
+	}
+	return rows
+}
+
+// fieldPriority of the built-in fields, from highest to lowest,
+// fields not listed have the lowest priority
+var fieldPriority = []string{
+	"sql",
+	logrus.ErrorKey,
+	"elapsed_ms",
+	"duration_ms",
+	"sql_truncated",
+	"error_code",
+	"error_type",
+	"timeout",
+	"lock_timeout",
+	"deadline_risk",
+	"rows",
+	"has_rows",
+	"zero_rows",
+	"rows_semantic",
+	"slow",
+	"slowLog",
+	"slow_ratio",
+	"elapsed_threshold",
+	"slow_count",
+	"sampled",
+	"file",
+	"caller_file",
+	"caller_line",
+	"operation",
+	"kind",
+	"query_id",
+	"trace_id",
+	"span_id",
+	"attempt",
+	"batch_size",
+	"batch_index",
+	"statement_index",
+	"table",
+	"model",
+	"literals",
+	"end_time",
+	"plan",
+}
+
+// WithNestedField pack the trace fields into a single key field, as a map, instead of the top level
+func WithNestedField(key string) Option {
+	return func(opt *options) {
+		opt.nestedField = key
+	}
+}
+
+// shapeFields merge the trace fields over the entry fields, then apply the max fields cap and nest the trace fields,
+// elapsedKey being the key of the elapsed field, ranked with it when set by WithElapsedFormatter
+func (l *Logger) shapeFields(ctx context.Context, fields logrus.Fields, elapsedKey string) logrus.Fields {
+	merged := l.entryFields(ctx, fields)
+	if l.maxFields > 0 && len(merged) > l.maxFields {
+		merged = capFields(merged, l.maxFields, elapsedKey)
+	}
+	if l.nestedField == "" {
+		return merged
+	}
+	shaped := logrus.Fields{}
+	nested := make(map[string]interface{}, len(fields))
+	for k, v := range merged {
+		if _, ok := fields[k]; !ok {
+			shaped[k] = v
+			continue
+		}
+		if err, ok := v.(error); ok {
+			// formatters only render errors at the top level
+			v = err.Error()
+		}
+		nested[k] = v
+	}
+	shaped[l.nestedField] = nested
+	return shaped
+}
+
+// WithMaxFields cap the number of fields of the trace entries to n, dropping the lowest priority fields first,
+// the static, context and WithFields ones included, as well as the ones nested by WithNestedField, but not the
+// fields of the WithEntry or WithContextLogger entries, added by logrus, a zero or negative n means no limit
+func WithMaxFields(n int) Option {
+	return func(opt *options) {
+		opt.maxFields = n
+	}
+}
+
+// capFields return a copy of fields without the lowest priority ones beyond max,
+// the elapsedKey field ranking with elapsed_ms
+func capFields(fields logrus.Fields, max int, elapsedKey string) logrus.Fields {
+	rank := make(map[string]int, len(fieldPriority)+1)
+	for i, key := range fieldPriority {
+		rank[key] = i
+	}
+	if _, ok := rank[elapsedKey]; !ok && elapsedKey != "" {
+		rank[elapsedKey] = rank["elapsed_ms"]
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ri, iok := rank[keys[i]]
+		rj, jok := rank[keys[j]]
+		switch {
+		case iok && jok && ri != rj:
+			return ri < rj
+		case iok != jok:
+			return iok
+		default:
+			return keys[i] < keys[j]
+		}
+	})
+	capped := make(logrus.Fields, max)
+	for _, key := range keys[:max] {
+		capped[key] = fields[key]
+	}
+	return capped
+}
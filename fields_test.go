@@ -0,0 +1,63 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"testing"
+	"time"
+)
+
+func TestMaxFieldsKeepsSQLErrorAndElapsed(t *testing.T) {
+	l, hook := newTestLogger(
+		WithMaxFields(3),
+		WithQueryMessage("sql"),
+		WithErrorMessage("sql error"),
+		WithStaticFields(logrus.Fields{"service": "orders", "zone": "eu"}),
+	)
+	ctx := WithFields(context.Background(), logrus.Fields{"request_id": "r1"})
+
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, errors.New("failed"))
+	entry := hook.LastEntry()
+	if len(entry.Data) != 3 {
+		t.Fatalf("got fields %v, want 3 fields, the static and context ones counted", entry.Data)
+	}
+	for _, key := range []string{"sql", logrus.ErrorKey, "elapsed_ms"} {
+		if _, ok := entry.Data[key]; !ok {
+			t.Errorf("got fields %v, want the %s field kept", entry.Data, key)
+		}
+	}
+}
+
+func TestMaxFieldsNested(t *testing.T) {
+	l, hook := newTestLogger(
+		WithMaxFields(2),
+		WithQueryMessage("sql"),
+		WithNestedField("db"),
+		WithStaticFields(logrus.Fields{"service": "orders"}),
+	)
+	l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	data := hook.LastEntry().Data
+	nested, _ := data["db"].(map[string]interface{})
+	if len(data) != 1 || len(nested) != 2 || nested["sql"] != "SELECT 1" {
+		t.Errorf("got fields %v, want the sql and elapsed_ms fields nested only", data)
+	}
+}
+
+func TestStaticFields(t *testing.T) {
+	l, hook := newTestLogger(
+		WithStaticFields(logrus.Fields{"component": "gorm", "db": "orders", "file": "static"}),
+		WithStaticFields(logrus.Fields{"db": "orders_eu", "shard": 3}),
+	)
+	ctx := context.Background()
+	l.Info(ctx, "info message")
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 0 }, errors.New("failed"))
+	for _, entry := range hook.AllEntries() {
+		if entry.Data["component"] != "gorm" || entry.Data["db"] != "orders_eu" || entry.Data["shard"] != 3 {
+			t.Errorf("got %q fields %v, want the merged static fields, the later ones winning", entry.Message, entry.Data)
+		}
+	}
+	if file := hook.LastEntry().Data["file"]; file == "static" {
+		t.Error("got the static file field, want the per-call one winning")
+	}
+}
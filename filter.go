@@ -0,0 +1,57 @@
+package gorm_logrus
+
+import (
+	"regexp"
+)
+
+// WithExcludeSQL skip the log of the successful and slow queries whose sql matches one of the patterns,
+// e.g. `^SELECT 1$` for health checks, use (?i) for a case-insensitive match, panic on an invalid pattern,
+// exclusions win over inclusions and failed queries are still logged unless WithExcludeSQLForErrors is set
+func WithExcludeSQL(patterns ...string) Option {
+	excludes := compilePatterns(patterns)
+	return func(opt *options) {
+		opt.excludeSQL = append(opt.excludeSQL, excludes...)
+	}
+}
+
+// WithIncludeSQL only log the successful and slow queries whose sql matches one of the patterns,
+// e.g. `(?i)\borders\b` while debugging a table, panic on an invalid pattern
+func WithIncludeSQL(patterns ...string) Option {
+	includes := compilePatterns(patterns)
+	return func(opt *options) {
+		opt.includeSQL = append(opt.includeSQL, includes...)
+	}
+}
+
+// WithExcludeSQLForErrors apply the WithExcludeSQL and WithIncludeSQL filters to the failed queries as well
+func WithExcludeSQLForErrors(enabled bool) Option {
+	return func(opt *options) {
+		opt.filterErrors = enabled
+	}
+}
+
+func compilePatterns(patterns []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		res = append(res, regexp.MustCompile(pattern))
+	}
+	return res
+}
+
+// filteredSQL report whether the log of sql is skipped by the include and exclude filters
+func (l *Logger) filteredSQL(sql string) bool {
+	for _, re := range l.excludeSQL {
+		if re.MatchString(sql) {
+			return true
+		}
+	}
+	if len(l.includeSQL) == 0 {
+		return false
+	}
+	for _, re := range l.includeSQL {
+		if re.MatchString(sql) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSQLFilters(t *testing.T) {
+	health := "SELECT 1"
+	orders := "SELECT * FROM `orders` WHERE `id` = 1"
+	users := "SELECT * FROM `users` WHERE `id` = 1"
+	tests := []struct {
+		name   string
+		opts   []Option
+		sql    string
+		slow   bool
+		err    error
+		logged bool
+	}{
+		{name: "excluded", opts: []Option{WithExcludeSQL(`^SELECT 1$`)}, sql: health},
+		{name: "excluded slow", opts: []Option{WithExcludeSQL(`^SELECT 1$`)}, sql: health, slow: true},
+		{name: "not excluded", opts: []Option{WithExcludeSQL(`^SELECT 1$`)}, sql: users, logged: true},
+		{name: "excluded error still logged", opts: []Option{WithExcludeSQL(`^SELECT 1$`)}, sql: health, err: errors.New("failed"), logged: true},
+		{name: "excluded error filtered", opts: []Option{WithExcludeSQL(`^SELECT 1$`), WithExcludeSQLForErrors(true)}, sql: health, err: errors.New("failed")},
+		{name: "included", opts: []Option{WithIncludeSQL("(?i)`ORDERS`")}, sql: orders, logged: true},
+		{name: "not included", opts: []Option{WithIncludeSQL("(?i)`ORDERS`")}, sql: users},
+		{name: "exclusion wins", opts: []Option{WithIncludeSQL("orders"), WithExcludeSQL("orders")}, sql: orders},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, hook := newTestLogger(append(tt.opts, WithSlowThreshold(time.Second))...)
+			begin := time.Now()
+			if tt.slow {
+				begin = slowBegin(time.Second)
+			}
+			l.Trace(context.Background(), begin, func() (string, int64) { return tt.sql, 1 }, tt.err)
+			if logged := hook.LastEntry() != nil; logged != tt.logged {
+				t.Errorf("got the query logged %v, want %v", logged, tt.logged)
+			}
+		})
+	}
+}
+
+func TestSQLFiltersInvalidPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic, want the invalid pattern rejected by the option")
+		}
+	}()
+	WithExcludeSQL("(")
+}
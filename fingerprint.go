@@ -0,0 +1,46 @@
+package gorm_logrus
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	fingerprintLiteral = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|\b\d+(?:\.\d+)?\b`)
+	fingerprintList    = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	fingerprintSpace   = regexp.MustCompile(`\s+`)
+)
+
+// maxFingerprints tracked by a fingerprintCounter before it is reset
+const maxFingerprints = 10000
+
+// fingerprint normalize sql into its shape: literals replaced by ?,
+// lists of values collapsed and whitespace squeezed
+func fingerprint(sql string) string {
+	sql = fingerprintLiteral.ReplaceAllString(sql, "?")
+	sql = fingerprintList.ReplaceAllString(sql, "(?)")
+	sql = fingerprintSpace.ReplaceAllString(sql, " ")
+	return strings.TrimSpace(sql)
+}
+
+// fingerprintCounter count occurrences per fingerprint, safe for concurrent use
+type fingerprintCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newFingerprintCounter() *fingerprintCounter {
+	return &fingerprintCounter{counts: map[string]int{}}
+}
+
+// inc increment the count of fp and return it
+func (c *fingerprintCounter) inc(fp string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.counts[fp]; !ok && len(c.counts) >= maxFingerprints {
+		c.counts = map[string]int{}
+	}
+	c.counts[fp]++
+	return c.counts[fp]
+}
@@ -3,6 +3,7 @@ module github.com/taotao2tingbao/gorm-logrus
 go 1.16
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/sirupsen/logrus v1.8.1
-	gorm.io/gorm v1.21.15
+	gorm.io/gorm v1.24.3
 )
@@ -0,0 +1,84 @@
+package gorm_logrus
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// testDialector a minimal gorm dialector over a sqlmock connection, binding the vars as ? or as $n
+type testDialector struct {
+	conn   gorm.ConnPool
+	dollar bool
+}
+
+var dollarPlaceholder = regexp.MustCompile(`\$(\d+)`)
+
+func (d testDialector) Name() string {
+	return "test"
+}
+
+func (d testDialector) Initialize(db *gorm.DB) error {
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	db.ConnPool = d.conn
+	return nil
+}
+
+func (d testDialector) Migrator(*gorm.DB) gorm.Migrator {
+	return nil
+}
+
+func (d testDialector) DataTypeOf(*schema.Field) string {
+	return ""
+}
+
+func (d testDialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+
+func (d testDialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, _ interface{}) {
+	if d.dollar {
+		writer.WriteString("$" + strconv.Itoa(len(stmt.Vars)))
+		return
+	}
+	writer.WriteByte('?')
+}
+
+func (d testDialector) QuoteTo(writer clause.Writer, str string) {
+	writer.WriteByte('"')
+	writer.WriteString(str)
+	writer.WriteByte('"')
+}
+
+func (d testDialector) Explain(sql string, vars ...interface{}) string {
+	if d.dollar {
+		return logger.ExplainSQL(sql, dollarPlaceholder, `'`, vars...)
+	}
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
+
+// openTestDB open a gorm DB logging to l over a sqlmock connection
+func openTestDB(t *testing.T, l logger.Interface, dollar bool) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db, err := gorm.Open(testDialector{conn: conn, dollar: dollar}, &gorm.Config{Logger: l, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+type testUser struct {
+	ID    uint
+	Email string
+}
@@ -0,0 +1,63 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// TraceEvent a traced query, passed to the trace hooks
+type TraceEvent struct {
+	Ctx     context.Context
+	Begin   time.Time
+	Elapsed time.Duration
+	SQL     string
+	Rows    int64
+	Err     error
+	Slow    bool
+}
+
+// WithTraceHook call hook for every traced query, whatever is logged, Silent LogMode included in which
+// case the sql is built for the hooks only, e.g. to record metrics, the hooks are called in registration
+// order and a panicking one is recovered, the first panic being logged at Error
+func WithTraceHook(hook func(ev TraceEvent)) Option {
+	return func(opt *options) {
+		if hook != nil {
+			opt.traceHooks = append(opt.traceHooks, hook)
+		}
+	}
+}
+
+// hookPanic log the first panic of the trace hooks
+type hookPanic struct {
+	once sync.Once
+}
+
+// runTraceHooks call the trace hooks with the event of t
+func (l *Logger) runTraceHooks(t *traceCall) {
+	sql, rows := t.result()
+	ev := TraceEvent{
+		Ctx:     t.ctx,
+		Begin:   t.begin,
+		Elapsed: t.elapsed,
+		SQL:     sql,
+		Rows:    rows,
+		Err:     t.err,
+		Slow:    l.isSlow(t),
+	}
+	for _, hook := range l.traceHooks {
+		l.runTraceHook(hook, ev)
+	}
+}
+
+func (l *Logger) runTraceHook(hook func(ev TraceEvent), ev TraceEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.hookPanic.once.Do(func() {
+				l.logf(ev.Ctx, logrus.ErrorLevel, logrus.Fields{"panic": r}, "gorm logger trace hook panicked: %v", r)
+			})
+		}
+	}()
+	hook(ev)
+}
@@ -0,0 +1,45 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+	"testing"
+	"time"
+)
+
+func TestTraceHooks(t *testing.T) {
+	var order []string
+	var events []TraceEvent
+	failed := errors.New("failed")
+	l, hook := newTestLogger(
+		WithLogLevel(logger.Silent),
+		WithSlowThreshold(time.Second),
+		WithTraceHook(func(ev TraceEvent) {
+			order = append(order, "first")
+			events = append(events, ev)
+		}),
+		WithTraceHook(func(TraceEvent) { panic("boom") }),
+		WithTraceHook(func(TraceEvent) { order = append(order, "third") }),
+	)
+	ctx := context.Background()
+	l.Trace(ctx, slowBegin(time.Second), func() (string, int64) { return "SELECT 1", 3 }, nil)
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 2", 0 }, failed)
+
+	if len(order) != 4 || order[0] != "first" || order[1] != "third" {
+		t.Errorf("got the hooks called in order %q, want first then third for both calls", order)
+	}
+	if ev := events[0]; ev.SQL != "SELECT 1" || ev.Rows != 3 || !ev.Slow || ev.Err != nil || ev.Elapsed < 2*time.Second {
+		t.Errorf("got the event %+v, want the slow SELECT 1", ev)
+	}
+	if ev := events[1]; ev.SQL != "SELECT 2" || ev.Slow || ev.Err != failed {
+		t.Errorf("got the event %+v, want the failed SELECT 2", ev)
+	}
+
+	// Silent logs nothing but the first panic of the hooks
+	entries := hook.AllEntries()
+	if len(entries) != 1 || entries[0].Level != logrus.ErrorLevel || entries[0].Data["panic"] != "boom" {
+		t.Errorf("got %d entries, want the first hook panic only", len(entries))
+	}
+}
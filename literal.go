@@ -0,0 +1,180 @@
+package gorm_logrus
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// literal span of a literal value in an interpolated sql
+type literal struct {
+	start, end int
+	quoted     bool
+}
+
+// sqlLiterals return the best-effort spans of the literal values of sql, in order:
+// single quoted strings, numbers, NULL, TRUE and FALSE, identifiers and comments are skipped
+func sqlLiterals(sql string) []literal {
+	var literals []literal
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == '\\' {
+					j += 2
+					continue
+				}
+				if sql[j] == '\'' {
+					if j+1 < len(sql) && sql[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j >= len(sql) {
+				j = len(sql) - 1
+			}
+			literals = append(literals, literal{start: i, end: j + 1, quoted: true})
+			i = j + 1
+		case c == '"' || c == '`':
+			j := strings.IndexByte(sql[i+1:], c)
+			if j < 0 {
+				return literals
+			}
+			i += j + 2
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				return literals
+			}
+			i += j + 1
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			j := strings.Index(sql[i:], "*/")
+			if j < 0 {
+				return literals
+			}
+			i += j + 2
+		case isIdentChar(c):
+			j := i
+			for j < len(sql) && (isIdentChar(sql[j]) || sql[j] == '.' && isDigit(c)) {
+				j++
+			}
+			word := sql[i:j]
+			if isDigit(c) || strings.EqualFold(word, "NULL") || strings.EqualFold(word, "TRUE") || strings.EqualFold(word, "FALSE") {
+				start := i
+				if isDigit(c) && i > 0 && sql[i-1] == '-' && (i == 1 || !isIdentChar(sql[i-2])) {
+					start--
+				}
+				literals = append(literals, literal{start: start, end: j})
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return literals
+}
+
+// WithRedactArgPositions mask the literal values at the 0-based positions in the logged sql,
+// best-effort as only the interpolated sql is available: the literals are located by sqlLiterals
+func WithRedactArgPositions(positions []int) Option {
+	return func(opt *options) {
+		opt.redactPositions = append([]int(nil), positions...)
+		sort.Ints(opt.redactPositions)
+	}
+}
+
+// redactLiterals replace the literals of sql at positions with a mask
+func redactLiterals(sql string, positions []int) string {
+	literals := sqlLiterals(sql)
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, pos := range positions {
+		if pos < 0 || pos >= len(literals) {
+			continue
+		}
+		lit := literals[pos]
+		if lit.start < last {
+			continue
+		}
+		b.WriteString(sql[last:lit.start])
+		b.WriteString("'***'")
+		last = lit.end
+	}
+	if last == 0 {
+		return sql
+	}
+	b.WriteString(sql[last:])
+	return b.String()
+}
+
+// WithExtractLiterals log the literal values of the interpolated sql as the literals array field,
+// approximate as they are parsed back by sqlLiterals: quoted strings, numbers, NULL and booleans,
+// extracted from the sql scrubbed by WithSQLRedactor, the WithRedactArgPositions ones are masked and
+// nothing is extracted unless the full sql is logged
+func WithExtractLiterals(enabled bool) Option {
+	return func(opt *options) {
+		opt.extractLiterals = enabled
+	}
+}
+
+// literals return the literals field of sql, parsed from the sql scrubbed by the redactor
+func (l *Logger) literals(sql string) []interface{} {
+	if l.sqlRedactor != nil {
+		sql = l.sqlRedactor(sql)
+	}
+	return literalValues(sql, l.redactPositions)
+}
+
+// literalValues return the values of the literals of sql, masking the ones at redacted positions
+func literalValues(sql string, redacted []int) []interface{} {
+	literals := sqlLiterals(sql)
+	values := make([]interface{}, len(literals))
+	for i, lit := range literals {
+		values[i] = literalValue(sql[lit.start:lit.end], lit.quoted)
+	}
+	for _, pos := range redacted {
+		if pos >= 0 && pos < len(values) {
+			values[pos] = "***"
+		}
+	}
+	return values
+}
+
+// literalValue return the Go value of a single literal
+func literalValue(s string, quoted bool) interface{} {
+	if quoted {
+		s = strings.TrimPrefix(s, "'")
+		s = strings.TrimSuffix(s, "'")
+		return strings.NewReplacer("''", "'", "\\'", "'", "\\\\", "\\").Replace(s)
+	}
+	switch {
+	case strings.EqualFold(s, "NULL"):
+		return nil
+	case strings.EqualFold(s, "TRUE"):
+		return true
+	case strings.EqualFold(s, "FALSE"):
+		return false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
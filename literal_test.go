@@ -0,0 +1,25 @@
+package gorm_logrus
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestExtractLiteralsWithRedactor(t *testing.T) {
+	password := regexp.MustCompile(`'[^']*secret[^']*'`)
+	l, hook := newTestLogger(
+		WithExtractLiterals(true),
+		WithSQLRedactor(func(sql string) string { return password.ReplaceAllString(sql, "'***'") }),
+		WithRedactArgPositions([]int{2}),
+	)
+	l.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return "UPDATE `users` SET `password` = 'my-secret', `name` = 'alice' WHERE `id` = 42", 1
+	}, nil)
+	got := hook.LastEntry().Data["literals"]
+	if want := []interface{}{"***", "alice", "***"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got literals %v, want %v, the redacted values masked", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package gorm_logrus
+
+import (
+	"gorm.io/gorm/logger"
+	"sync/atomic"
+	"time"
+)
+
+// liveConfig the config fields adjustable at runtime, read and written atomically
+type liveConfig struct {
+	logLevel      int64
+	slowThreshold int64
+}
+
+func newLiveConfig(cfg logger.Config) *liveConfig {
+	return &liveConfig{logLevel: int64(cfg.LogLevel), slowThreshold: int64(cfg.SlowThreshold)}
+}
+
+// SetLogLevel change the LogLevel of the logger in place, safe to call while queries are traced,
+// the loggers returned by LogMode earlier keep their own level
+func (l *Logger) SetLogLevel(level logger.LogLevel) {
+	atomic.StoreInt64(&l.live.logLevel, int64(level))
+}
+
+// SetSlowThreshold change the SlowThreshold of the logger in place, safe to call while queries are traced,
+// the loggers returned by LogMode earlier keep their own threshold
+func (l *Logger) SetSlowThreshold(threshold time.Duration) {
+	atomic.StoreInt64(&l.live.slowThreshold, int64(threshold))
+}
+
+// config return the config in effect, with the live fields
+func (l *Logger) config() logger.Config {
+	cfg := l.cfg
+	cfg.LogLevel = logger.LogLevel(atomic.LoadInt64(&l.live.logLevel))
+	cfg.SlowThreshold = time.Duration(atomic.LoadInt64(&l.live.slowThreshold))
+	return cfg
+}
@@ -0,0 +1,54 @@
+package gorm_logrus
+
+import (
+	"context"
+	"gorm.io/gorm/logger"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetLogLevelAndSlowThreshold(t *testing.T) {
+	l, hook := newTestLogger(WithLogLevel(logger.Silent))
+	ctx := context.Background()
+	fc := func() (string, int64) { return "SELECT 1", 1 }
+	l.Trace(ctx, time.Now(), fc, nil)
+	if len(hook.AllEntries()) != 0 {
+		t.Fatal("got an entry, want none at the Silent level")
+	}
+
+	l.SetLogLevel(logger.Warn)
+	l.SetSlowThreshold(time.Second)
+	l.Trace(ctx, time.Now(), fc, nil)
+	l.Trace(ctx, slowBegin(time.Second), fc, nil)
+	if entries := hook.AllEntries(); len(entries) != 1 || entries[0].Data["slowLog"] == nil {
+		t.Errorf("got %d entries, want the slow query only", len(entries))
+	}
+}
+
+func TestSetLogLevelConcurrent(t *testing.T) {
+	l, _ := newTestLogger(WithLogLevel(logger.Info))
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.SetLogLevel(logger.LogLevel(1 + i%4))
+				l.SetSlowThreshold(time.Duration(i) * time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+	l.SetLogLevel(logger.Error)
+	if got := l.config().LogLevel; got != logger.Error {
+		t.Errorf("got level %v, want Error", got)
+	}
+}
@@ -2,104 +2,659 @@ package gorm_logrus
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/utils"
+	"math/rand"
+	"regexp"
+	"sync/atomic"
 	"time"
 )
 
 type (
 	Option  func(opt *options)
 	options struct {
-		log *logrus.Logger
-		cfg logger.Config
+		log     *logrus.Logger
+		entry   *logrus.Entry
+		backend ContextLogger
+		cfg     logger.Config
+
+		disableContext          bool
+		hostField               bool
+		pidField                bool
+		staticFields            logrus.Fields
+		userFields              logrus.Fields
+		skipThresholdCheck      bool
+		poolStatsLevel          *logrus.Level
+		splitCaller             bool
+		mergeSlowAndError       bool
+		sanitizeSQL             bool
+		summaryOnly             bool
+		firstErrorSQLOnly       bool
+		queryMessage            string
+		slowMessage             string
+		errorMessage            string
+		explainDB               *sql.DB
+		explainOpts             *explainOptions
+		explainer               *explainer
+		maxFields               int
+		endTimeField            bool
+		timeLocation            *time.Location
+		sqlFormatter            func(sql string) string
+		redactPositions         []int
+		rowsSentinel            RowsSentinel
+		noSlowDetection         bool
+		attemptKey              interface{}
+		errorTypeField          bool
+		errorCodeField          bool
+		uniformLevel            *logrus.Level
+		noElapsedField          bool
+		elapsedFormatter        func(elapsed time.Duration) (key string, value interface{})
+		deadlineRiskMargin      time.Duration
+		periodicInterval        time.Duration
+		operationField          bool
+		tableField              bool
+		sqlMode                 SQLMode
+		periodicSize            int
+		periodic                *periodicSummary
+		counters                *counters
+		errorCodeExtractors     []ErrorCodeExtractor
+		callerMinElapsed        time.Duration
+		callerMinRows           int64
+		callerResolver          func() string
+		callerSkips             []string
+		noCaller                bool
+		batchFields             bool
+		auditMode               bool
+		auditLevel              *logrus.Level
+		contextRewriter         func(ctx context.Context) context.Context
+		splitStatements         bool
+		tableAllowlist          map[string]bool
+		slowWarmup              time.Duration
+		created                 time.Time
+		queryKindField          bool
+		onSlow                  func(ctx context.Context, sql string, rows int64, elapsed time.Duration)
+		contextEntryKey         interface{}
+		fieldOrder              []string
+		migrationField          bool
+		migrationLevel          *logrus.Level
+		queryIDField            bool
+		suppressZeroRows        map[string]bool
+		nestedField             string
+		slowLogger              *logrus.Logger
+		slowBackend             ContextLogger
+		slowLogOnly             bool
+		operationSlowThresholds map[string]time.Duration
+		minOperationThreshold   time.Duration
+		timeoutField            bool
+		timeoutMatcher          func(err error) bool
+		lockTimeoutMatcher      func(err error) bool
+		lockTimeoutLevel        *logrus.Level
+		metrics                 Metrics
+		repeatedSlowThreshold   int
+		nPlusOneThreshold       int
+		prepareCounts           *prepareCounter
+		samplingRate            float64
+		samplingSource          rand.Source
+		sampler                 *sampler
+		sampledField            bool
+		levelMapper             func(branch Branch) logrus.Level
+		contextExtractors       []ContextExtractor
+		extractLiterals         bool
+		discard                 bool
+		rowsSemantic            bool
+		structuredFields        bool
+		parameterizedQueries    bool
+		sqlRedactor             func(sql string) string
+		maxSQLLength            int
+		queryLimiter            *windowLimiter
+		ignoredErrors           []error
+		slowThresholdFunc       func(ctx context.Context, sql string, elapsed time.Duration) bool
+		slowLevel               *logrus.Level
+		live                    *liveConfig
+		traceHooks              []func(ev TraceEvent)
+		hookPanic               *hookPanic
+		excludeSQL              []*regexp.Regexp
+		includeSQL              []*regexp.Regexp
+		filterErrors            bool
+		warnOnZeroRows          map[string]bool
+		traceFormatter          func(elapsed time.Duration, rows int64, sql string) string
+		contextLogger           func(ctx context.Context) *logrus.Entry
+		asyncBufferSize         int
+		async                   *asyncQueue
+		errorLevels             []errorLevel
+		loggerRouter            func(branch Branch) *logrus.Logger
+		routedBackends          map[Branch]ContextLogger
+		errorLogger             *logrus.Logger
+		slowBranchLogger        *logrus.Logger
+		samplingReportInterval  time.Duration
+		errorLimiter            *tokenBucket
+		fastErrorThreshold      time.Duration
+		slowCounts              *fingerprintCounter
 	}
 )
 
+const (
+	traceFormat          = "[%.3fms] [rows:%v] %s"
+	traceFormatNoElapsed = "[rows:%v] %s"
+)
+
+// minSlowThreshold below which SlowThreshold is most likely a units mistake
+const minSlowThreshold = time.Millisecond
+
+// structuredMessage the message of the queries logged by WithStructuredFields
+const structuredMessage = "sql trace"
+
 func WithLogger(log *logrus.Logger) Option {
 	return func(opt *options) {
 		opt.log = log
+		opt.entry = nil
+	}
+}
+
+// WithEntry log through entry, inheriting its fields, and through its logger hooks and formatter,
+// a nil entry, or one without logger, keeping the current logger
+func WithEntry(entry *logrus.Entry) Option {
+	return func(opt *options) {
+		if entry == nil || entry.Logger == nil {
+			return
+		}
+		opt.entry = entry
+		opt.log = entry.Logger
 	}
 }
 
+// WithConfig set the whole gorm logger config, its LogLevel gates the Trace branches like the gorm logger does,
+// a zero LogLevel logging everything as Info, the options setting a single field override it when given after it
 func WithConfig(cfg logger.Config) Option {
 	return func(opt *options) {
 		opt.cfg = cfg
 	}
 }
 
+// WithDefaults set the config of the gorm default logger: Warn LogLevel and 200ms SlowThreshold,
+// the defaults of New being to log everything without slow detection
+func WithDefaults() Option {
+	return func(opt *options) {
+		opt.cfg.LogLevel = logger.Warn
+		opt.cfg.SlowThreshold = 200 * time.Millisecond
+	}
+}
+
+// WithSlowThreshold set the SlowThreshold of the config
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(opt *options) {
+		opt.cfg.SlowThreshold = threshold
+	}
+}
+
+// WithLogLevel set the LogLevel of the config
+func WithLogLevel(level logger.LogLevel) Option {
+	return func(opt *options) {
+		opt.cfg.LogLevel = level
+	}
+}
+
+// WithIgnoreRecordNotFoundError set the IgnoreRecordNotFoundError of the config
+func WithIgnoreRecordNotFoundError(ignore bool) Option {
+	return func(opt *options) {
+		opt.cfg.IgnoreRecordNotFoundError = ignore
+	}
+}
+
+// WithThresholdCheck enable or disable the SlowThreshold sanity check done by New, enabled by default
+func WithThresholdCheck(enabled bool) Option {
+	return func(opt *options) {
+		opt.skipThresholdCheck = !enabled
+	}
+}
+
+// WithSplitCaller log the caller as separate caller_file and caller_line fields instead of file
+func WithSplitCaller(split bool) Option {
+	return func(opt *options) {
+		opt.splitCaller = split
+	}
+}
+
+// WithMergeSlowAndError attach the slow query fields to errors of queries that are also slow
+func WithMergeSlowAndError(merge bool) Option {
+	return func(opt *options) {
+		opt.mergeSlowAndError = merge
+	}
+}
+
+// WithQueryMessage log queries with a static message, moving sql, rows and elapsed_ms to fields
+func WithQueryMessage(msg string) Option {
+	return func(opt *options) {
+		opt.queryMessage = msg
+	}
+}
+
+// WithSlowMessage log slow queries with a static message, moving sql, rows and elapsed_ms to fields
+func WithSlowMessage(msg string) Option {
+	return func(opt *options) {
+		opt.slowMessage = msg
+	}
+}
+
+// WithErrorMessage log failed queries with a static message, moving sql, rows and elapsed_ms to fields
+func WithErrorMessage(msg string) Option {
+	return func(opt *options) {
+		opt.errorMessage = msg
+	}
+}
+
+// WithTraceFormatter set the func formatting the message of the traced queries logged without a static message,
+// instead of "[12.345ms] [rows:3] SELECT ...", rows being -1 when unknown
+func WithTraceFormatter(formatter func(elapsed time.Duration, rows int64, sql string) string) Option {
+	return func(opt *options) {
+		opt.traceFormatter = formatter
+	}
+}
+
+// WithStructuredFields log every query with the constant "sql trace" message unless set otherwise, sql, rows,
+// duration_ms, slow and file becoming fields, instead of "[12.345ms] [rows:3] SELECT ..." messages,
+// disabling it restores the formatted messages, keeping the messages set otherwise
+func WithStructuredFields(enabled bool) Option {
+	return func(opt *options) {
+		opt.structuredFields = enabled
+		for _, msg := range []*string{&opt.queryMessage, &opt.slowMessage, &opt.errorMessage} {
+			switch {
+			case enabled && *msg == "":
+				*msg = structuredMessage
+			case !enabled && *msg == structuredMessage:
+				*msg = ""
+			}
+		}
+	}
+}
+
+// WithContextEntry merge the fields of the *logrus.Entry stored in the context under key,
+// as done by logrus middlewares, into every entry
+func WithContextEntry(key interface{}) Option {
+	return func(opt *options) {
+		opt.contextEntryKey = key
+	}
+}
+
+// WithRepeatedSlowEscalation log slow queries at Error once their fingerprint has been slow n times,
+// the count is logged as the slow_count field
+func WithRepeatedSlowEscalation(n int) Option {
+	return func(opt *options) {
+		opt.repeatedSlowThreshold = n
+		opt.slowCounts = nil
+		if n > 0 {
+			opt.slowCounts = newFingerprintCounter()
+		}
+	}
+}
+
+// WithOnSlow call fn for every slow query, after it is logged, with the sql as logged
+func WithOnSlow(fn func(ctx context.Context, sql string, rows int64, elapsed time.Duration)) Option {
+	return func(opt *options) {
+		opt.onSlow = fn
+	}
+}
+
+// WithUniformLevel log all the traced queries at level, failed and slow ones included,
+// slow queries then get a slow=true field to tell them apart, failures having the error field
+func WithUniformLevel(level logrus.Level) Option {
+	return func(opt *options) {
+		opt.uniformLevel = &level
+	}
+}
+
+// WithElapsedField enable or disable logging the elapsed time of queries, enabled by default
+func WithElapsedField(enabled bool) Option {
+	return func(opt *options) {
+		opt.noElapsedField = !enabled
+	}
+}
+
+// WithElapsedFormatter set how the elapsed time field is rendered, default to elapsed_ms as a float, the field
+// is logged as well when the sql is formatted into the message, which keeps its own elapsed milliseconds
+func WithElapsedFormatter(formatter func(elapsed time.Duration) (key string, value interface{})) Option {
+	return func(opt *options) {
+		opt.elapsedFormatter = formatter
+	}
+}
+
+// WithSlowDetection enable or disable the slow query detection, enabled by default,
+// when disabled the queries are logged as successful ones whatever their duration
+func WithSlowDetection(enabled bool) Option {
+	return func(opt *options) {
+		opt.noSlowDetection = !enabled
+	}
+}
+
+// WithSlowThresholdFunc set the func reporting whether a query is slow, replacing the SlowThreshold check,
+// e.g. to allow more time to the aggregation queries
+func WithSlowThresholdFunc(fn func(ctx context.Context, sql string, elapsed time.Duration) bool) Option {
+	return func(opt *options) {
+		opt.slowThresholdFunc = fn
+	}
+}
+
+// WithSlowLevel log the slow queries at level instead of Warn
+func WithSlowLevel(level logrus.Level) Option {
+	return func(opt *options) {
+		opt.slowLevel = &level
+	}
+}
+
+// WithSlowWarmup log the slow queries as successful ones during the first d after New,
+// avoiding the slow query storm of the cold caches at startup
+func WithSlowWarmup(d time.Duration) Option {
+	return func(opt *options) {
+		opt.slowWarmup = d
+	}
+}
+
+// WithErrorTypeField log the concrete type of query errors as the error_type field, e.g. *mysql.MySQLError
+func WithErrorTypeField(enabled bool) Option {
+	return func(opt *options) {
+		opt.errorTypeField = enabled
+	}
+}
+
+// WithWarnOnFastErrors log errors of queries faster than threshold at Warn instead of Error,
+// for transient failures retried right away such as deadlocks
+func WithWarnOnFastErrors(threshold time.Duration) Option {
+	return func(opt *options) {
+		opt.fastErrorThreshold = threshold
+	}
+}
+
 type Logger struct {
-	log *logrus.Logger
-	cfg logger.Config
+	options
 }
 
 func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
 	newLogger := *l
+	newLogger.cfg = l.config()
 	newLogger.cfg.LogLevel = level
+	newLogger.live = newLiveConfig(newLogger.cfg)
 	return &newLogger
 }
 
+// Logger return the underlying logrus logger, to log through the same hooks and formatter
+func (l *Logger) Logger() *logrus.Logger {
+	return l.log
+}
+
 // Info print info
 func (l *Logger) Info(ctx context.Context, msg string, data ...interface{}) {
-	l.log.WithContext(ctx).Infof(msg, data...)
+	if l.discard || l.logLevel(ctx) < logger.Info {
+		return
+	}
+	ctx = l.rewriteContext(ctx)
+	l.logTo(l.branchBackend(BranchInfo), ctx, l.branchLevel(BranchInfo), nil, msg, data...)
 }
 
 // Warn print warn messages
 func (l *Logger) Warn(ctx context.Context, msg string, data ...interface{}) {
-	l.log.WithContext(ctx).Warnf(msg, data...)
+	if l.discard || l.logLevel(ctx) < logger.Warn {
+		return
+	}
+	ctx = l.rewriteContext(ctx)
+	l.logTo(l.branchBackend(BranchWarn), ctx, l.branchLevel(BranchWarn), nil, msg, data...)
 }
 
 // Error print error messages
 func (l *Logger) Error(ctx context.Context, msg string, data ...interface{}) {
-	l.log.WithContext(ctx).Errorf(msg, data...)
+	if l.discard || l.logLevel(ctx) < logger.Error {
+		return
+	}
+	ctx = l.rewriteContext(ctx)
+	l.logTo(l.branchBackend(BranchErrorMsg), ctx, l.branchLevel(BranchErrorMsg), nil, msg, data...)
 }
 
 // Trace print sql message
 func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.discard {
+		return
+	}
+	ctx = l.rewriteContext(ctx)
 	elapsed := time.Since(begin)
+	if elapsed < 0 {
+		// clock skew, don't log a negative duration
+		elapsed = 0
+	}
+	level := l.logLevel(ctx)
+	failed := l.shouldLogError(err)
+	// counted whatever the level, Stats reporting the queries even when none is logged
+	l.counters.count(failed, l.thresholdSlow(ctx, elapsed))
+	if level <= logger.Silent && len(l.traceHooks) == 0 || l.traceSkipped(ctx, level, elapsed, failed) {
+		return
+	}
+	t := &traceCall{ctx: ctx, begin: begin, elapsed: elapsed, fc: fc, err: err}
+	if l.gormCaller(t) {
+		t.file = utils.FileWithLineNum()
+	}
+	if len(l.traceHooks) > 0 {
+		l.runTraceHooks(t)
+	}
+	if level <= logger.Silent {
+		return
+	}
+	t.summary = requestSummaryFrom(ctx)
+	if t.summary != nil {
+		t.summary.add(l, elapsed, func() string {
+			return l.formatSQL(t.sql())
+		})
+	}
+	l.observe(t, failed)
+	l.detectNPlusOne(t)
+	l.countPrepare(t)
+	l.periodic.add(t)
 	switch {
-	case err != nil && (!errors.Is(err, gorm.ErrRecordNotFound) || !l.cfg.IgnoreRecordNotFoundError):
-		sql, rows := fc()
-		if rows == -1 {
-			l.log.WithContext(ctx).WithFields(logrus.Fields{
-				"file":          utils.FileWithLineNum(),
-				logrus.ErrorKey: err,
-			}).Errorf("[%.3fms] [rows:%v] %s", float64(elapsed.Nanoseconds())/1e6, "-", sql)
-		} else {
-			l.log.WithContext(ctx).WithFields(logrus.Fields{
-				"file":          utils.FileWithLineNum(),
-				logrus.ErrorKey: err,
-			}).Errorf("[%.3fms] [rows:%v] %s", float64(elapsed.Nanoseconds())/1e6, rows, sql)
-		}
-	case elapsed > l.cfg.SlowThreshold && l.cfg.SlowThreshold != 0:
-		sql, rows := fc()
-		slowLog := fmt.Sprintf("SLOW SQL >= %v", l.cfg.SlowThreshold)
-		if rows == -1 {
-			l.log.WithContext(ctx).WithFields(logrus.Fields{
-				"file":    utils.FileWithLineNum(),
-				"slowLog": slowLog,
-			}).Warnf("[%.3fms] [rows:%v] %s", float64(elapsed.Nanoseconds())/1e6, "-", sql)
-		} else {
-			l.log.WithContext(ctx).WithFields(logrus.Fields{
-				"file":    utils.FileWithLineNum(),
-				"slowLog": slowLog,
-			}).Warnf("[%.3fms] [rows:%v] %s", float64(elapsed.Nanoseconds())/1e6, rows, sql)
-		}
+	case failed && level >= logger.Error:
+		l.traceError(t)
+	case l.auditMode:
+		l.traceAudit(t, level)
+	case l.slowBranchEnabled(ctx) && l.slowLogged(t, level):
+		l.traceSlow(t)
+	case level >= logger.Info && (t.summary == nil || !l.summaryOnly), level >= logger.Warn && l.warnOnZeroRows != nil:
+		l.traceQuery(t, level)
+	}
+}
+
+// traceSkipped report whether no branch of Trace can emit the call, nor anything else use it,
+// checked before any work is done for the call
+func (l *Logger) traceSkipped(ctx context.Context, level logger.LogLevel, elapsed time.Duration, failed bool) bool {
+	switch {
+	case len(l.traceHooks) > 0 || l.metrics != nil || l.periodic != nil || l.nPlusOneThreshold > 0 || l.prepareCounts != nil:
+		return false
+	case requestSummaryFrom(ctx) != nil:
+		return false
+	case failed:
+		// the level of errors depends on the error
+		return false
+	case level >= logger.Warn && l.maybeSlow(ctx, elapsed) && l.slowBranchEnabled(ctx):
+		return false
+	case l.auditMode:
+		return !l.levelEnabled(ctx, l.branchBackend(BranchQuery), l.auditBranchLevel())
+	case level >= logger.Warn && l.warnOnZeroRows != nil:
+		return !l.queryBranchEnabled(ctx)
+	}
+	return level < logger.Info || !l.queryBranchEnabled(ctx)
+}
+
+// entryFields return the fields of an entry, from lowest to highest precedence: the static fields,
+// the fields of the entry stored in ctx, the context extractors ones, the WithFields ones and fields
+func (l *Logger) entryFields(ctx context.Context, fields logrus.Fields) logrus.Fields {
+	return mergeFields(l.staticFields, l.contextEntryFields(ctx), l.extractedFields(ctx), contextFields(ctx), fields)
+}
+
+func (l *Logger) contextEntryFields(ctx context.Context) logrus.Fields {
+	if l.contextEntryKey == nil || ctx == nil {
+		return nil
+	}
+	if ctxEntry, ok := ctx.Value(l.contextEntryKey).(*logrus.Entry); ok && ctxEntry != nil {
+		return ctxEntry.Data
+	}
+	return nil
+}
+
+// logf log the formatted message at level, when the backend has it enabled
+func (l *Logger) logf(ctx context.Context, level logrus.Level, fields logrus.Fields, format string, args ...interface{}) {
+	l.logTo(l.backend, ctx, level, fields, format, args...)
+}
+
+// logTo log the formatted message at level to backend, when it has it enabled
+func (l *Logger) logTo(backend ContextLogger, ctx context.Context, level logrus.Level, fields logrus.Fields, format string, args ...interface{}) {
+	if !enabled(backend, ctx, level) {
+		return
+	}
+	backend.Log(ctx, level, l.entryFields(ctx, fields), fmt.Sprintf(format, args...))
+}
+
+// logTrace log the traced sql at level to backend, formatted into the message unless a
+// static msg is set, in which case sql, rows and elapsed_ms become fields
+func (l *Logger) logTrace(backend ContextLogger, ctx context.Context, level logrus.Level, fields logrus.Fields, msg string, elapsed time.Duration, sql string, rows int64) {
+	if l.uniformLevel != nil {
+		level = *l.uniformLevel
+	}
+	var truncated bool
+	if sql, truncated = l.truncateSQL(sql); truncated {
+		fields["sql_truncated"] = true
+	}
+	var elapsedKey string
+	if l.elapsedFormatter != nil && !l.noElapsedField {
+		var value interface{}
+		elapsedKey, value = l.elapsedFormatter(elapsed)
+		fields[elapsedKey] = value
+	}
+	switch {
+	case msg == "" && l.traceFormatter != nil:
+		l.logTraceEntry(backend, ctx, level, fields, elapsedKey, l.traceFormatter(elapsed, rows, sql))
+		return
+	case msg == "" && !l.noElapsedField && colored(backend, ctx):
+		l.logTraceEntry(backend, ctx, level, fields, elapsedKey, colorTrace(fields, elapsedMs(elapsed), rowsValue(rows), sql))
+		return
+	case msg == "" && l.noElapsedField:
+		l.logTraceEntry(backend, ctx, level, fields, "", fmt.Sprintf(traceFormatNoElapsed, rowsValue(rows), sql))
+		return
+	case msg == "":
+		l.logTraceEntry(backend, ctx, level, fields, elapsedKey, fmt.Sprintf(traceFormat, elapsedMs(elapsed), rowsValue(rows), sql))
+		return
+	}
+	fields["sql"] = sql
+	l.rowsFields(fields, rows)
+	switch {
+	case l.noElapsedField || elapsedKey != "":
+	case l.structuredFields:
+		elapsedKey, fields["duration_ms"] = "duration_ms", elapsedMs(elapsed)
 	default:
-		sql, rows := fc()
-		if rows == -1 {
-			l.log.WithContext(ctx).WithFields(logrus.Fields{
-				"file": utils.FileWithLineNum(),
-			}).Debugf("[%.3fms] [rows:%v] %s", float64(elapsed.Nanoseconds())/1e6, "-", sql)
-		} else {
-			l.log.WithContext(ctx).WithFields(logrus.Fields{
-				"file": utils.FileWithLineNum(),
-			}).Debugf("[%.3fms] [rows:%v] %s", float64(elapsed.Nanoseconds())/1e6, rows, sql)
+		elapsedKey, fields["elapsed_ms"] = "elapsed_ms", elapsedMs(elapsed)
+	}
+	l.logTraceEntry(backend, ctx, level, fields, elapsedKey, msg)
+}
+
+// logTraceEntry log a traced query at level to backend, when it has it enabled,
+// elapsedKey being the key of the elapsed field if any, see shapeFields
+func (l *Logger) logTraceEntry(backend ContextLogger, ctx context.Context, level logrus.Level, fields logrus.Fields, elapsedKey, msg string) {
+	if !enabled(backend, ctx, level) {
+		return
+	}
+	backend.Log(ctx, level, l.shapeFields(ctx, fields, elapsedKey), msg)
+}
+
+// shouldLogError report whether err is logged as a failed query: any error
+// but ErrRecordNotFound when IgnoreRecordNotFoundError is set
+func (l *Logger) shouldLogError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if l.cfg.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound) {
+		return false
+	}
+	return !l.ignoredError(err)
+}
+
+// isSlow report whether the call is a slow query, detected once per call
+func (l *Logger) isSlow(t *traceCall) bool {
+	if !t.slowDetected {
+		t.slow, t.slowDetected = l.detectSlow(t), true
+	}
+	return t.slow
+}
+
+// detectSlow report whether the call is a slow query, with the slow threshold func when set
+func (l *Logger) detectSlow(t *traceCall) bool {
+	switch {
+	case !l.maybeSlow(t.ctx, t.elapsed):
+		return false
+	case l.slowThresholdFunc != nil:
+		return l.slowThresholdFunc(t.ctx, t.sql(), t.elapsed)
+	}
+	threshold := l.slowThreshold(t)
+	return t.elapsed > threshold && threshold != 0
+}
+
+// maybeSlow report whether a call taking elapsed can be a slow query, without building its sql
+func (l *Logger) maybeSlow(ctx context.Context, elapsed time.Duration) bool {
+	switch {
+	case l.noSlowDetection:
+		return false
+	case l.slowThresholdFunc != nil:
+		return true
+	}
+	if opt := callOptionsFrom(ctx); opt != nil && opt.slowThreshold != nil {
+		return elapsed > *opt.slowThreshold && *opt.slowThreshold != 0
+	}
+	threshold := time.Duration(atomic.LoadInt64(&l.live.slowThreshold))
+	return elapsed > threshold && threshold != 0 ||
+		elapsed > l.minOperationThreshold && l.minOperationThreshold != 0
+}
+
+// thresholdSlow report whether a call taking elapsed is slower than the SlowThreshold in effect,
+// ignoring the operation thresholds and the slow threshold func, which need the sql
+func (l *Logger) thresholdSlow(ctx context.Context, elapsed time.Duration) bool {
+	if l.noSlowDetection {
+		return false
+	}
+	threshold := time.Duration(atomic.LoadInt64(&l.live.slowThreshold))
+	if opt := callOptionsFrom(ctx); opt != nil && opt.slowThreshold != nil {
+		threshold = *opt.slowThreshold
+	}
+	return elapsed > threshold && threshold != 0
+}
+
+// slowThreshold return the slow threshold in effect for the call: the CallSlowThreshold one,
+// the operation one or the configured one
+func (l *Logger) slowThreshold(t *traceCall) time.Duration {
+	if opt := callOptionsFrom(t.ctx); opt != nil && opt.slowThreshold != nil {
+		return *opt.slowThreshold
+	}
+	if len(l.operationSlowThresholds) > 0 {
+		if threshold, ok := l.operationSlowThresholds[sqlOperation(t.sql())]; ok {
+			return threshold
 		}
 	}
+	return time.Duration(atomic.LoadInt64(&l.live.slowThreshold))
+}
+
+// slowLogged report whether the call is logged as a slow query at level
+func (l *Logger) slowLogged(t *traceCall, level logger.LogLevel) bool {
+	return level >= logger.Warn && (l.slowWarmup <= 0 || t.begin.Sub(l.created) >= l.slowWarmup) && l.isSlow(t)
+}
+
+// logLevel return the gorm log level in effect for the call, the CallLogLevel one
+// or the configured one, Info when none is set
+func (l *Logger) logLevel(ctx context.Context) logger.LogLevel {
+	if opt := callOptionsFrom(ctx); opt != nil && opt.logLevel != 0 {
+		return opt.logLevel
+	}
+	level := logger.LogLevel(atomic.LoadInt64(&l.live.logLevel))
+	if level == 0 {
+		return logger.Info
+	}
+	return level
 }
 
 func New(opts ...Option) logger.Interface {
@@ -110,8 +665,39 @@ func New(opts ...Option) logger.Interface {
 	if opt.log == nil {
 		opt.log = logrus.StandardLogger()
 	}
-	return &Logger{
-		log: opt.log,
-		cfg: opt.cfg,
+	opt.staticFields = mergeFields(instanceFields(opt.hostField, opt.pidField), opt.userFields)
+	custom := opt.backend != nil
+	if !custom {
+		opt.backend = opt.logrusBackend(opt.log)
+	}
+	primary := opt.chainBackend(opt.backend, nil)
+	if opt.asyncBufferSize > 0 {
+		// the queue reports its drops to the primary backend directly, chainBackend making the routed ones async
+		opt.async = newAsyncQueue(primary, opt.asyncBufferSize)
+		primary = asyncBackend{queue: opt.async, backend: primary}
+	}
+	opt.backend = primary
+	if !custom {
+		opt.routedBackends = newRoutedBackends(&opt)
+		if opt.slowLogger != nil {
+			opt.slowBackend = opt.chainBackend(opt.logrusBackend(opt.slowLogger), opt.slowLogger)
+		}
+	}
+	opt.created = time.Now()
+	opt.live = newLiveConfig(opt.cfg)
+	opt.hookPanic = &hookPanic{}
+	opt.counters = &counters{}
+	opt.sampler = newSampler(opt.samplingRate, opt.samplingSource)
+	if !opt.skipThresholdCheck && opt.cfg.SlowThreshold > 0 && opt.cfg.SlowThreshold < minSlowThreshold && opt.backend.Enabled(logrus.WarnLevel) {
+		opt.backend.Log(context.Background(), logrus.WarnLevel, nil,
+			fmt.Sprintf("gorm logger SlowThreshold is %v, did you mean %v?", opt.cfg.SlowThreshold, opt.cfg.SlowThreshold*time.Millisecond))
+	}
+	l := &Logger{
+		options: opt,
 	}
+	l.periodic = newPeriodicSummary(opt.periodicInterval, opt.periodicSize, l)
+	l.explainer = newExplainer(opt.explainDB, opt.explainOpts, l)
+	l.sampler.report(l, opt.samplingReportInterval)
+	l.errorLimiter.report(l, errorRateLimitReportInterval)
+	return l
 }
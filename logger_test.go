@@ -0,0 +1,377 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLogger return a logger writing to a logrus test hook, with the logrus level at Trace
+func newTestLogger(opts ...Option) (*Logger, *test.Hook) {
+	log, hook := test.NewNullLogger()
+	log.SetLevel(logrus.TraceLevel)
+	return New(append([]Option{WithLogger(log)}, opts...)...).(*Logger), hook
+}
+
+// countingTrace return a Trace fc returning sql and rows, and the number of times it was called
+func countingTrace(sql string, rows int64) (func() (string, int64), *int) {
+	calls := new(int)
+	return func() (string, int64) {
+		*calls++
+		return sql, rows
+	}, calls
+}
+
+// slowBegin return a begin time making the query slower than threshold
+func slowBegin(threshold time.Duration) time.Time {
+	return time.Now().Add(-2 * threshold)
+}
+
+func TestTraceLogLevelGating(t *testing.T) {
+	errQuery := errors.New("query failed")
+	type branch struct {
+		name  string
+		begin func() time.Time
+		err   error
+		level logrus.Level
+	}
+	branches := []branch{
+		{name: "error", begin: time.Now, err: errQuery, level: logrus.ErrorLevel},
+		{name: "slow", begin: func() time.Time { return slowBegin(time.Second) }, level: logrus.WarnLevel},
+		{name: "query", begin: time.Now, level: logrus.DebugLevel},
+	}
+	tests := []struct {
+		level  logger.LogLevel
+		logged map[string]bool
+	}{
+		{level: logger.Silent, logged: map[string]bool{}},
+		{level: logger.Error, logged: map[string]bool{"error": true}},
+		{level: logger.Warn, logged: map[string]bool{"error": true, "slow": true}},
+		{level: logger.Info, logged: map[string]bool{"error": true, "slow": true, "query": true}},
+	}
+	for _, tt := range tests {
+		for _, b := range branches {
+			l, hook := newTestLogger(WithLogLevel(tt.level), WithSlowThreshold(time.Second))
+			fc, calls := countingTrace("SELECT * FROM users", 1)
+			l.Trace(context.Background(), b.begin(), fc, b.err)
+
+			entries := hook.AllEntries()
+			if !tt.logged[b.name] {
+				if len(entries) != 0 {
+					t.Errorf("level %d, %s: got %d entries, want none", tt.level, b.name, len(entries))
+				}
+				if *calls != 0 {
+					t.Errorf("level %d, %s: fc called %d times, want none", tt.level, b.name, *calls)
+				}
+				continue
+			}
+			if len(entries) != 1 {
+				t.Fatalf("level %d, %s: got %d entries, want 1", tt.level, b.name, len(entries))
+			}
+			if entries[0].Level != b.level {
+				t.Errorf("level %d, %s: logged at %s, want %s", tt.level, b.name, entries[0].Level, b.level)
+			}
+			if *calls != 1 {
+				t.Errorf("level %d, %s: fc called %d times, want 1", tt.level, b.name, *calls)
+			}
+		}
+	}
+}
+
+func TestTraceLogModeSilent(t *testing.T) {
+	l, hook := newTestLogger(WithLogLevel(logger.Info))
+	fc, calls := countingTrace("SELECT 1", 1)
+	l.LogMode(logger.Silent).Trace(context.Background(), time.Now(), fc, errors.New("query failed"))
+	if len(hook.AllEntries()) != 0 || *calls != 0 {
+		t.Errorf("Silent LogMode: got %d entries and %d fc calls, want none", len(hook.AllEntries()), *calls)
+	}
+
+	l.Trace(context.Background(), time.Now(), fc, nil)
+	if len(hook.AllEntries()) != 1 {
+		t.Errorf("parent logger: got %d entries, want 1", len(hook.AllEntries()))
+	}
+}
+
+func TestElapsedFormatterInBothModes(t *testing.T) {
+	formatter := WithElapsedFormatter(func(elapsed time.Duration) (string, interface{}) {
+		return "elapsed", elapsed.String()
+	})
+	for name, opts := range map[string][]Option{
+		"formatted message": {formatter},
+		"static message":    {formatter, WithQueryMessage("sql")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			l, hook := newTestLogger(opts...)
+			l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+			data := hook.LastEntry().Data
+			if _, ok := data["elapsed"].(string); !ok {
+				t.Errorf("got fields %v, want the elapsed field of the formatter", data)
+			}
+			if _, ok := data["elapsed_ms"]; ok {
+				t.Errorf("got fields %v, want no elapsed_ms field", data)
+			}
+		})
+	}
+}
+
+func TestWithEntryNil(t *testing.T) {
+	for name, entry := range map[string]*logrus.Entry{"nil": nil, "without logger": {}} {
+		t.Run(name, func(t *testing.T) {
+			l, hook := newTestLogger(WithEntry(entry))
+			l.Info(context.Background(), "info message")
+			if entry := hook.LastEntry(); entry == nil || entry.Message != "info message" {
+				t.Errorf("got %v, want the message logged by the current logger", entry)
+			}
+		})
+	}
+}
+
+func TestWithStructuredFieldsDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "enabled", opts: []Option{WithStructuredFields(true)}, want: structuredMessage},
+		{name: "disabled after enabled", opts: []Option{WithStructuredFields(true), WithStructuredFields(false)}, want: "ms] [rows:1] SELECT 1"},
+		{name: "custom message kept", opts: []Option{WithQueryMessage("sql"), WithStructuredFields(true), WithStructuredFields(false)}, want: "sql"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, hook := newTestLogger(tt.opts...)
+			l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+			if got := hook.LastEntry().Message; !strings.HasSuffix(got, tt.want) {
+				t.Errorf("got message %q, want it ending with %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceClampsNegativeElapsed(t *testing.T) {
+	for name, begin := range map[string]time.Time{
+		"negative":                     time.Now().Add(time.Hour),
+		"negative below a millisecond": time.Now().Add(time.Millisecond),
+	} {
+		t.Run(name, func(t *testing.T) {
+			l, hook := newTestLogger()
+			l.Trace(context.Background(), begin, func() (string, int64) { return "SELECT 1", 1 }, nil)
+			if got := hook.LastEntry().Message; got != "[0.000ms] [rows:1] SELECT 1" {
+				t.Errorf("got message %q, want a zero elapsed", got)
+			}
+
+			l, hook = newTestLogger(WithQueryMessage("sql"))
+			l.Trace(context.Background(), begin, func() (string, int64) { return "SELECT 1", 1 }, nil)
+			if got := hook.LastEntry().Data["elapsed_ms"]; got != 0.0 {
+				t.Errorf("got elapsed_ms %v, want 0", got)
+			}
+		})
+	}
+}
+
+func TestShouldLogError(t *testing.T) {
+	other := errors.New("failed")
+	tests := []struct {
+		name   string
+		ignore bool
+		err    error
+		want   bool
+	}{
+		{name: "not found ignored", ignore: true, err: gorm.ErrRecordNotFound, want: false},
+		{name: "wrapped not found ignored", ignore: true, err: fmt.Errorf("find: %w", gorm.ErrRecordNotFound), want: false},
+		{name: "other error with ignore", ignore: true, err: other, want: true},
+		{name: "not found logged", err: gorm.ErrRecordNotFound, want: true},
+		{name: "other error", err: other, want: true},
+		{name: "no error", ignore: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, hook := newTestLogger(WithIgnoreRecordNotFoundError(tt.ignore))
+			if got := l.shouldLogError(tt.err); got != tt.want {
+				t.Errorf("got shouldLogError %v, want %v", got, tt.want)
+			}
+			l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 0 }, tt.err)
+			if logged := hook.LastEntry().Level == logrus.ErrorLevel; logged != tt.want {
+				t.Errorf("got the query logged at %s, want it logged as an error %v", hook.LastEntry().Level, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessagesLogLevelGating(t *testing.T) {
+	tests := []struct {
+		level logger.LogLevel
+		want  []string
+	}{
+		{level: logger.Silent},
+		{level: logger.Error, want: []string{"error message"}},
+		{level: logger.Warn, want: []string{"warn message", "error message"}},
+		{level: logger.Info, want: []string{"info message", "warn message", "error message"}},
+	}
+	for _, tt := range tests {
+		t.Run(levelName(tt.level), func(t *testing.T) {
+			l, hook := newTestLogger()
+			lm := l.LogMode(tt.level)
+			ctx := context.Background()
+			lm.Info(ctx, "info %s", "message")
+			lm.Warn(ctx, "warn %s", "message")
+			lm.Error(ctx, "error %s", "message")
+			var got []string
+			for _, entry := range hook.AllEntries() {
+				got = append(got, entry.Message)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigOptionsOverrideFieldByField(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want logger.Config
+	}{
+		{name: "default"},
+		{
+			name: "fields after config",
+			opts: []Option{WithConfig(logger.Config{SlowThreshold: time.Second, LogLevel: logger.Warn}), WithLogLevel(logger.Error), WithIgnoreRecordNotFoundError(true)},
+			want: logger.Config{SlowThreshold: time.Second, LogLevel: logger.Error, IgnoreRecordNotFoundError: true},
+		},
+		{
+			name: "config after fields",
+			opts: []Option{WithSlowThreshold(time.Second), WithConfig(logger.Config{LogLevel: logger.Warn})},
+			want: logger.Config{LogLevel: logger.Warn},
+		},
+		{
+			name: "later field wins",
+			opts: []Option{WithSlowThreshold(time.Second), WithSlowThreshold(200 * time.Millisecond)},
+			want: logger.Config{SlowThreshold: 200 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLogger(tt.opts...)
+			if l.cfg != tt.want {
+				t.Errorf("got config %+v, want %+v", l.cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceDefaultFormat(t *testing.T) {
+	var elapsed time.Duration
+	l, hook := newTestLogger(WithTraceHook(func(ev TraceEvent) { elapsed = ev.Elapsed }))
+	for _, tt := range []struct {
+		rows int64
+		want string
+	}{
+		{rows: 3, want: "[%.3fms] [rows:3] SELECT * FROM `users`"},
+		{rows: -1, want: "[%.3fms] [rows:-] SELECT * FROM `users`"},
+	} {
+		l.Trace(context.Background(), time.Now().Add(-12345*time.Microsecond), func() (string, int64) { return "SELECT * FROM `users`", tt.rows }, nil)
+		want := fmt.Sprintf(tt.want, float64(elapsed.Nanoseconds())/1e6)
+		if got := hook.LastEntry().Message; got != want {
+			t.Errorf("got message %q, want %q", got, want)
+		}
+	}
+}
+
+func TestTraceFormatter(t *testing.T) {
+	l, hook := newTestLogger(WithSlowThreshold(time.Second), WithTraceFormatter(func(elapsed time.Duration, rows int64, sql string) string {
+		return fmt.Sprintf("duration=%s rows=%d | %s", elapsed.Truncate(time.Second), rows, sql)
+	}))
+	ctx := context.Background()
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", -1 }, nil)
+	l.Trace(ctx, slowBegin(time.Second), func() (string, int64) { return "SELECT 2", 2 }, nil)
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 3", 0 }, errors.New("failed"))
+
+	entries := hook.AllEntries()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want the query, the slow query and the error", len(entries))
+	}
+	for i, want := range []string{"duration=0s rows=-1 | SELECT 1", "duration=2s rows=2 | SELECT 2", "duration=0s rows=0 | SELECT 3"} {
+		if entries[i].Message != want {
+			t.Errorf("got message %q, want %q", entries[i].Message, want)
+		}
+		if entries[i].Data["file"] == nil {
+			t.Errorf("got fields %v, want the file field kept", entries[i].Data)
+		}
+	}
+	if entries[1].Data["slowLog"] == nil || entries[2].Data[logrus.ErrorKey] == nil {
+		t.Errorf("got fields %v and %v, want the slowLog and error fields kept", entries[1].Data, entries[2].Data)
+	}
+}
+
+func TestStructuredFieldsKeySets(t *testing.T) {
+	tests := []struct {
+		structured bool
+		want       map[logrus.Level][]string
+	}{
+		{structured: true, want: map[logrus.Level][]string{
+			logrus.DebugLevel: {"duration_ms", "file", "rows", "slow", "sql"},
+			logrus.WarnLevel:  {"duration_ms", "elapsed_threshold", "file", "rows", "slow", "slowLog", "sql"},
+			logrus.ErrorLevel: {"duration_ms", "error", "file", "rows", "slow", "sql"},
+		}},
+		{structured: false, want: map[logrus.Level][]string{
+			logrus.DebugLevel: {"file"},
+			logrus.WarnLevel:  {"elapsed_threshold", "file", "slowLog"},
+			logrus.ErrorLevel: {"error", "file"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("structured %v", tt.structured), func(t *testing.T) {
+			l, hook := newTestLogger(WithStructuredFields(tt.structured), WithSlowThreshold(time.Second))
+			ctx := context.Background()
+			l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+			l.Trace(ctx, slowBegin(time.Second), func() (string, int64) { return "SELECT 1", 1 }, nil)
+			l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, errors.New("failed"))
+
+			entries := hook.AllEntries()
+			if len(entries) != 3 {
+				t.Fatalf("got %d entries, want the query, the slow query and the error", len(entries))
+			}
+			for _, entry := range entries {
+				keys := make([]string, 0, len(entry.Data))
+				for key := range entry.Data {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				if want := tt.want[entry.Level]; strings.Join(keys, ",") != strings.Join(want, ",") {
+					t.Errorf("got the %s fields %q, want %q", entry.Level, keys, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithEntryFieldsOnEveryMethod(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	log.SetLevel(logrus.TraceLevel)
+	l := New(WithEntry(log.WithFields(logrus.Fields{"service": "orders", "region": "eu"})), WithSlowThreshold(time.Second))
+	ctx := context.Background()
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	l.Trace(ctx, slowBegin(time.Second), func() (string, int64) { return "SELECT 2", 1 }, nil)
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 3", 0 }, errors.New("failed"))
+	l.Info(ctx, "info message")
+	l.Warn(ctx, "warn message")
+	l.Error(ctx, "error message")
+
+	entries := hook.AllEntries()
+	if len(entries) != 6 {
+		t.Fatalf("got %d entries, want the 3 traces and the 3 messages", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Data["service"] != "orders" || entry.Data["region"] != "eu" {
+			t.Errorf("got %q fields %v, want the fields of the WithEntry entry", entry.Message, entry.Data)
+		}
+	}
+}
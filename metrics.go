@@ -0,0 +1,41 @@
+package gorm_logrus
+
+import (
+	"time"
+)
+
+// Metrics receive the duration and failure of every traced query, whatever the log level but Silent
+type Metrics interface {
+	ObserveDuration(d time.Duration)
+	IncError()
+}
+
+// LabeledMetrics Metrics observing durations labeled by operation and table as well,
+// used instead of ObserveDuration when the Metrics passed to WithMetrics implements it
+type LabeledMetrics interface {
+	Metrics
+	ObserveDurationLabeled(op, table string, d time.Duration)
+}
+
+// WithMetrics report the traced queries to metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(opt *options) {
+		opt.metrics = metrics
+	}
+}
+
+// observe report a traced query to the metrics
+func (l *Logger) observe(t *traceCall, failed bool) {
+	if l.metrics == nil {
+		return
+	}
+	if labeled, ok := l.metrics.(LabeledMetrics); ok {
+		sql := t.sql()
+		labeled.ObserveDurationLabeled(operationLabel(sql), l.tableLabel(sqlTable(sql)), t.elapsed)
+	} else {
+		l.metrics.ObserveDuration(t.elapsed)
+	}
+	if failed {
+		l.metrics.IncError()
+	}
+}
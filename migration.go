@@ -0,0 +1,29 @@
+package gorm_logrus
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// WithMigrationField tag DDL statements, such as the ones issued by AutoMigrate, with migration=true
+func WithMigrationField(enabled bool) Option {
+	return func(opt *options) {
+		opt.migrationField = enabled
+	}
+}
+
+// WithMigrationLevel log the successful DDL statements at level instead of Debug
+func WithMigrationLevel(level logrus.Level) Option {
+	return func(opt *options) {
+		opt.migrationLevel = &level
+	}
+}
+
+// isDDL report whether sql is a DDL statement, best-effort: its leading keyword
+// is CREATE, ALTER, DROP, TRUNCATE, RENAME or COMMENT
+func isDDL(sql string) bool {
+	switch sqlVerb(sql) {
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "COMMENT":
+		return true
+	}
+	return false
+}
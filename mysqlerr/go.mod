@@ -0,0 +1,14 @@
+module github.com/taotao2tingbao/gorm-logrus/mysqlerr
+
+go 1.16
+
+require github.com/taotao2tingbao/gorm-logrus v1.0.0
+
+require (
+	github.com/go-sql-driver/mysql v1.7.1
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.4 // indirect
+	github.com/sirupsen/logrus v1.8.1
+	golang.org/x/sys v0.0.0-20191026070338-33540a1f6037 // indirect
+	gorm.io/gorm v1.24.3 // indirect
+)
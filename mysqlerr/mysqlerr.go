@@ -0,0 +1,33 @@
+// Package mysqlerr provide gorm_logrus helpers for the errors of the go-sql-driver/mysql driver
+//
+//	gorm_logrus.New(gorm_logrus.WithErrorCodeExtractors(mysqlerr.ErrorCode))
+package mysqlerr
+
+import (
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	gorm_logrus "github.com/taotao2tingbao/gorm-logrus"
+	"strconv"
+)
+
+var _ gorm_logrus.ErrorCodeExtractor = ErrorCode
+
+// errLockWaitTimeout ER_LOCK_WAIT_TIMEOUT, lock wait timeout exceeded
+const errLockWaitTimeout = 1205
+
+// ErrorCode return the error number of a *mysql.MySQLError, e.g. 1062 for a duplicate entry
+func ErrorCode(err error) (string, bool) {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return strconv.FormatUint(uint64(mysqlErr.Number), 10), true
+	}
+	return "", false
+}
+
+// IsLockTimeout report whether err is a lock wait timeout error (1205), to use with WithLockTimeoutMatcher
+//
+//	gorm_logrus.New(gorm_logrus.WithLockTimeoutMatcher(mysqlerr.IsLockTimeout))
+func IsLockTimeout(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errLockWaitTimeout
+}
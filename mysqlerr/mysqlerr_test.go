@@ -0,0 +1,53 @@
+package mysqlerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	gorm_logrus "github.com/taotao2tingbao/gorm-logrus"
+	"testing"
+	"time"
+)
+
+func TestErrorCode(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		err         error
+		code        string
+		lockTimeout bool
+	}{
+		{"duplicate entry", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"}, "1062", false},
+		{"lock wait timeout", &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, "1205", true},
+		{"wrapped", fmt.Errorf("create user: %w", &mysql.MySQLError{Number: 1205}), "1205", true},
+		{"other driver", errors.New("connection refused"), "", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			code, ok := ErrorCode(tt.err)
+			if code != tt.code || ok != (tt.code != "") {
+				t.Errorf("got ErrorCode %q %v, want %q", code, ok, tt.code)
+			}
+			if got := IsLockTimeout(tt.err); got != tt.lockTimeout {
+				t.Errorf("got IsLockTimeout %v, want %v", got, tt.lockTimeout)
+			}
+
+			log, hook := test.NewNullLogger()
+			log.SetLevel(logrus.TraceLevel)
+			l := gorm_logrus.New(gorm_logrus.WithLogger(log),
+				gorm_logrus.WithErrorCodeExtractors(ErrorCode), gorm_logrus.WithLockTimeoutMatcher(IsLockTimeout))
+			l.Trace(context.Background(), time.Now(), func() (string, int64) { return "INSERT INTO `users` (`id`) VALUES (1)", 0 }, tt.err)
+			entry := hook.LastEntry()
+			if entry == nil {
+				t.Fatal("got no entry, want the query error")
+			}
+			if got, ok := entry.Data["error_code"]; ok != (tt.code != "") || (ok && got != tt.code) {
+				t.Errorf("got the error_code field %v, want %q", entry.Data["error_code"], tt.code)
+			}
+			if _, ok := entry.Data["lock_timeout"]; ok != tt.lockTimeout {
+				t.Errorf("got the lock_timeout field %v, want it %v", ok, tt.lockTimeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package gorm_logrus
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// WithNPlusOneDetection log a warning when a query fingerprint is executed more than n times
+// within a single WithRequestSummary context, hinting at an N+1 query pattern
+func WithNPlusOneDetection(n int) Option {
+	return func(opt *options) {
+		opt.nPlusOneThreshold = n
+	}
+}
+
+// detectNPlusOne count the query in the request scope and log a warning the first
+// time its fingerprint goes over the threshold
+func (l *Logger) detectNPlusOne(t *traceCall) {
+	if l.nPlusOneThreshold <= 0 || t.summary == nil {
+		return
+	}
+	fp := fingerprint(t.sql())
+	if count := t.summary.fingerprints.inc(fp); count == l.nPlusOneThreshold+1 {
+		fields := logrus.Fields{}
+		if l.resolveCaller(t) {
+			fields = l.callerFields(l.caller(t))
+		}
+		fields["possible_n_plus_1"] = true
+		fields["count"] = count
+		fields["sql"] = l.formatSQL(fp)
+		l.logf(t.ctx, logrus.WarnLevel, fields, "possible N+1 query")
+	}
+}
@@ -0,0 +1,242 @@
+package gorm_logrus
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// sqlVerb return the upper cased leading keyword of sql,
+// skipping leading whitespace, comments and parentheses
+func sqlVerb(sql string) string {
+	sql = skipSQLPrefix(sql)
+	end := strings.IndexFunc(sql, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if end < 0 {
+		end = len(sql)
+	}
+	return strings.ToUpper(sql[:end])
+}
+
+// statementVerb return the upper cased verb of the main statement of sql, the one following
+// the common table expressions of a WITH query, e.g. UPDATE for WITH t AS (...) UPDATE ...
+func statementVerb(sql string) string {
+	verb := sqlVerb(sql)
+	if verb != "WITH" {
+		return verb
+	}
+	sql = skipSQLPrefix(sql)
+	depth := 0
+	for i := 0; i < len(sql); {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(sql, i)
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			depth--
+			i++
+		case isIdentChar(c):
+			j := i
+			for j < len(sql) && isIdentChar(sql[j]) {
+				j++
+			}
+			if depth == 0 {
+				switch word := strings.ToUpper(sql[i:j]); word {
+				case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE":
+					return word
+				}
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return verb
+}
+
+func skipSQLPrefix(sql string) string {
+	for {
+		sql = strings.TrimLeft(sql, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(sql, "--"), strings.HasPrefix(sql, "#"):
+			i := strings.IndexByte(sql, '\n')
+			if i < 0 {
+				return ""
+			}
+			sql = sql[i+1:]
+		case strings.HasPrefix(sql, "/*"):
+			i := strings.Index(sql, "*/")
+			if i < 0 {
+				return ""
+			}
+			sql = sql[i+2:]
+		default:
+			return sql
+		}
+	}
+}
+
+var sqlTablePattern = regexp.MustCompile("(?is)^\\s*(?:select\\b.*?\\bfrom|delete\\s+from|insert\\s+(?:ignore\\s+)?into|replace\\s+into|update)\\s+([`\"\\[]?[\\w.$]+[`\"\\]]?)")
+
+// sqlOperation return the lower cased operation of sql, e.g. select
+func sqlOperation(sql string) string {
+	return strings.ToLower(sqlVerb(sql))
+}
+
+// sqlTable return the best-effort main table of sql, without quotes
+func sqlTable(sql string) string {
+	m := sqlTablePattern.FindStringSubmatch(skipSQLPrefix(sql))
+	if m == nil {
+		return ""
+	}
+	return strings.Trim(m[1], "`\"[]")
+}
+
+// WithSuppressZeroRows skip the log of successful queries affecting zero rows for the given operations,
+// e.g. "insert" for idempotent upserts, errors and slow queries are still logged
+func WithSuppressZeroRows(operations ...string) Option {
+	return func(opt *options) {
+		opt.suppressZeroRows = operationSet(operations)
+	}
+}
+
+// operationSet return the set of the lower cased operations
+func operationSet(operations []string) map[string]bool {
+	if len(operations) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(operations))
+	for _, op := range operations {
+		set[strings.ToLower(op)] = true
+	}
+	return set
+}
+
+// WithOperationSlowThresholds override the slow threshold per operation, e.g. "select" or "update",
+// the operations not in thresholds use the configured SlowThreshold
+func WithOperationSlowThresholds(thresholds map[string]time.Duration) Option {
+	return func(opt *options) {
+		opt.operationSlowThresholds = make(map[string]time.Duration, len(thresholds))
+		opt.minOperationThreshold = 0
+		for op, threshold := range thresholds {
+			opt.operationSlowThresholds[strings.ToLower(op)] = threshold
+			if threshold > 0 && (opt.minOperationThreshold == 0 || threshold < opt.minOperationThreshold) {
+				opt.minOperationThreshold = threshold
+			}
+		}
+	}
+}
+
+// Query kinds logged by WithQueryKindField
+const (
+	QueryKindRead  = "read"
+	QueryKindWrite = "write"
+	QueryKindDDL   = "ddl"
+	QueryKindTx    = "tx"
+	QueryKindOther = "other"
+)
+
+// WithQueryKindField log the coarse kind of queries as the kind field, from their leading keyword:
+//
+//	read:  SELECT, SHOW, DESCRIBE, EXPLAIN, WITH
+//	write: INSERT, UPDATE, DELETE, REPLACE, MERGE, UPSERT
+//	ddl:   CREATE, ALTER, DROP, TRUNCATE, RENAME, COMMENT
+//	tx:    BEGIN, START, COMMIT, ROLLBACK, SAVEPOINT, RELEASE
+//	other: anything else
+func WithQueryKindField(enabled bool) Option {
+	return func(opt *options) {
+		opt.queryKindField = enabled
+	}
+}
+
+// queryKind return the kind of sql
+func queryKind(sql string) string {
+	switch verb := sqlVerb(sql); verb {
+	case "SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "WITH":
+		return QueryKindRead
+	case "INSERT", "UPDATE", "DELETE", "REPLACE", "MERGE", "UPSERT":
+		return QueryKindWrite
+	case "BEGIN", "START", "COMMIT", "ROLLBACK", "SAVEPOINT", "RELEASE":
+		return QueryKindTx
+	default:
+		if isDDL(sql) {
+			return QueryKindDDL
+		}
+		return QueryKindOther
+	}
+}
+
+// WithOperationField log the lower cased operation of queries as the operation field, e.g. select
+func WithOperationField(enabled bool) Option {
+	return func(opt *options) {
+		opt.operationField = enabled
+	}
+}
+
+// WithTableField log the main table of queries as the table field, parsed from the sql when
+// the ModelPlugin didn't provide it
+func WithTableField(enabled bool) Option {
+	return func(opt *options) {
+		opt.tableField = enabled
+	}
+}
+
+// unknownLabel the operation or table label replacing the values rejected by the cardinality guard
+const unknownLabel = "unknown"
+
+var (
+	operationLabelPattern = regexp.MustCompile(`^[a-z]{1,16}$`)
+	tableLabelPattern     = regexp.MustCompile(`^[A-Za-z_][\w$]{0,63}(?:\.[A-Za-z_][\w$]{0,63})?$`)
+)
+
+// WithTableAllowlist only log and label the given tables, the other ones are replaced by unknown,
+// default to accepting any table name looking like an identifier
+func WithTableAllowlist(tables []string) Option {
+	return func(opt *options) {
+		opt.tableAllowlist = make(map[string]bool, len(tables))
+		for _, table := range tables {
+			opt.tableAllowlist[strings.ToLower(table)] = true
+		}
+	}
+}
+
+// operationLabel return the operation of sql guarded against parser noise,
+// unknown when it isn't a short keyword
+func operationLabel(sql string) string {
+	if op := sqlOperation(sql); operationLabelPattern.MatchString(op) {
+		return op
+	}
+	return unknownLabel
+}
+
+// tableLabel return table guarded against parser noise, unknown when it isn't allowed
+// or doesn't look like a table name, an empty table is kept as is
+func (l *Logger) tableLabel(table string) string {
+	switch {
+	case table == "":
+		return ""
+	case l.tableAllowlist != nil:
+		if l.tableAllowlist[strings.ToLower(table)] {
+			return table
+		}
+	case tableLabelPattern.MatchString(table):
+		return table
+	}
+	return unknownLabel
+}
+
+// WithWarnOnZeroRows log at Warn with the zero_rows field the successful statements affecting zero rows
+// whose verb is one of verbs, default UPDATE and DELETE, e.g. an UPDATE whose WHERE matches nothing,
+// from the Warn gorm level up, they are neither sampled nor rate limited like the other successful queries,
+// fc being called for every successful query to count its rows
+func WithWarnOnZeroRows(verbs ...string) Option {
+	if len(verbs) == 0 {
+		verbs = []string{"update", "delete"}
+	}
+	return func(opt *options) {
+		opt.warnOnZeroRows = operationSet(verbs)
+	}
+}
@@ -0,0 +1,57 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestWarnOnZeroRows(t *testing.T) {
+	tests := []struct {
+		sql  string
+		rows int64
+		want logrus.Level
+	}{
+		{sql: "UPDATE `users` SET `name` = 'x' WHERE `id` = 1", want: logrus.WarnLevel},
+		{sql: "DELETE FROM `users` WHERE `id` = 1", want: logrus.WarnLevel},
+		{sql: "WITH ids AS (SELECT id FROM `users`) UPDATE `users` SET `name` = 'x' WHERE `id` IN (SELECT id FROM ids)", want: logrus.WarnLevel},
+		{sql: "UPDATE `users` SET `name` = 'x' WHERE `id` = 1", rows: 1, want: logrus.DebugLevel},
+		{sql: "SELECT * FROM `users` WHERE `id` = 1", want: logrus.DebugLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			l, hook := newTestLogger(WithWarnOnZeroRows())
+			l.Trace(context.Background(), time.Now(), func() (string, int64) { return tt.sql, tt.rows }, nil)
+			entry := hook.LastEntry()
+			if entry == nil || entry.Level != tt.want {
+				t.Fatalf("got %v, want the query logged at %s", entry, tt.want)
+			}
+			if _, ok := entry.Data["zero_rows"]; ok != (tt.want == logrus.WarnLevel) {
+				t.Errorf("got fields %v, want the zero_rows field on the warnings only", entry.Data)
+			}
+		})
+	}
+}
+
+func TestWarnOnZeroRowsAtWarnLevel(t *testing.T) {
+	l, hook := newTestLogger(WithWarnOnZeroRows(), WithSampling(0.01), WithSamplingSource(rand.NewSource(1)), WithRateLimit(1, time.Hour))
+	warn := l.LogMode(logger.Warn)
+	for i := 0; i < 3; i++ {
+		warn.Trace(context.Background(), time.Now(), func() (string, int64) { return "UPDATE `users` SET `name` = 'x'", 0 }, nil)
+	}
+	fc, calls := countingTrace("UPDATE `users` SET `name` = 'x'", 1)
+	warn.Trace(context.Background(), time.Now(), fc, nil)
+
+	entries := hook.AllEntries()
+	if len(entries) != 3 || *calls != 1 {
+		t.Fatalf("got %d entries, want the 3 zero rows warnings, neither sampled nor rate limited, and no successful query", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Level != logrus.WarnLevel {
+			t.Errorf("got %s, want Warn", entry.Level)
+		}
+	}
+}
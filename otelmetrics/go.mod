@@ -0,0 +1,18 @@
+module github.com/taotao2tingbao/gorm-logrus/otelmetrics
+
+go 1.16
+
+require (
+	github.com/taotao2tingbao/gorm-logrus v1.0.0
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/metric v1.28.0
+	go.opentelemetry.io/otel/sdk/metric v1.28.0
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.4 // indirect
+	github.com/sirupsen/logrus v1.8.1 // indirect
+	golang.org/x/sys v0.0.0-20191026070338-33540a1f6037 // indirect
+	gorm.io/gorm v1.24.3 // indirect
+)
@@ -0,0 +1,84 @@
+// Package otelmetrics provide a gorm_logrus.Metrics backed by the OpenTelemetry metrics API,
+// recording the query durations and errors with the db semantic convention attributes
+//
+//	m, err := otelmetrics.New(otel.Meter("gorm"), otelmetrics.WithDBSystem("mysql"))
+//	gorm_logrus.New(gorm_logrus.WithMetrics(m))
+package otelmetrics
+
+import (
+	"context"
+	gorm_logrus "github.com/taotao2tingbao/gorm-logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"time"
+)
+
+type (
+	Option  func(opt *options)
+	options struct {
+		system string
+	}
+)
+
+// WithDBSystem set the db.system attribute, e.g. mysql or postgresql
+func WithDBSystem(system string) Option {
+	return func(opt *options) {
+		opt.system = system
+	}
+}
+
+// Metrics implements gorm_logrus.LabeledMetrics
+type Metrics struct {
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+	attrs    []attribute.KeyValue
+}
+
+var _ gorm_logrus.LabeledMetrics = (*Metrics)(nil)
+
+// New create the instruments on meter
+func New(meter metric.Meter, opts ...Option) (*Metrics, error) {
+	var opt options
+	for _, o := range opts {
+		o(&opt)
+	}
+	duration, err := meter.Float64Histogram("db.client.operation.duration",
+		metric.WithDescription("Duration of database client operations."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	errors, err := meter.Int64Counter("db.client.operation.errors",
+		metric.WithDescription("Number of failed database client operations."),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return nil, err
+	}
+	m := &Metrics{duration: duration, errors: errors}
+	if opt.system != "" {
+		m.attrs = append(m.attrs, attribute.String("db.system", opt.system))
+	}
+	return m, nil
+}
+
+// ObserveDuration implements gorm_logrus.Metrics
+func (m *Metrics) ObserveDuration(d time.Duration) {
+	m.duration.Record(context.Background(), d.Seconds(), metric.WithAttributes(m.attrs...))
+}
+
+// ObserveDurationLabeled implements gorm_logrus.LabeledMetrics
+func (m *Metrics) ObserveDurationLabeled(op, table string, d time.Duration) {
+	attrs := append(make([]attribute.KeyValue, 0, len(m.attrs)+2), m.attrs...)
+	if op != "" {
+		attrs = append(attrs, attribute.String("db.operation.name", op))
+	}
+	if table != "" {
+		attrs = append(attrs, attribute.String("db.collection.name", table))
+	}
+	m.duration.Record(context.Background(), d.Seconds(), metric.WithAttributes(attrs...))
+}
+
+// IncError implements gorm_logrus.Metrics
+func (m *Metrics) IncError() {
+	m.errors.Add(context.Background(), 1, metric.WithAttributes(m.attrs...))
+}
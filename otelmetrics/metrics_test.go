@@ -0,0 +1,106 @@
+// metricdata is generic, newer than the go directive of the module
+//go:build go1.18
+
+package otelmetrics
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	gorm_logrus "github.com/taotao2tingbao/gorm-logrus"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"testing"
+	"time"
+)
+
+// collect trace the queries through a logger reporting to Metrics and return the collected metrics by name
+func collect(t *testing.T, queries []func() (string, int64), errs []error, opts ...Option) map[string]metricdata.Metrics {
+	reader := sdkmetric.NewManualReader()
+	m, err := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("gorm"), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, _ := test.NewNullLogger()
+	log.SetLevel(logrus.TraceLevel)
+	l := gorm_logrus.New(gorm_logrus.WithLogger(log), gorm_logrus.WithMetrics(m))
+	for i, fc := range queries {
+		l.Trace(context.Background(), time.Now().Add(-10*time.Millisecond), fc, errs[i])
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	metrics := make(map[string]metricdata.Metrics)
+	for _, sm := range rm.ScopeMetrics {
+		for _, metric := range sm.Metrics {
+			metrics[metric.Name] = metric
+		}
+	}
+	return metrics
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := collect(t, []func() (string, int64){
+		func() (string, int64) { return "SELECT * FROM `users` WHERE `id` = 1", 1 },
+		func() (string, int64) { return "SELECT * FROM `users` WHERE `id` = 2", 0 },
+		func() (string, int64) { return "UPDATE `orders` SET `paid` = true", 1 },
+	}, []error{nil, nil, errors.New("deadlock")}, WithDBSystem("mysql"))
+
+	duration, ok := metrics["db.client.operation.duration"].Data.(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("got %T, want the duration histogram", metrics["db.client.operation.duration"].Data)
+	}
+	counts := make(map[attribute.Distinct]uint64)
+	for _, dp := range duration.DataPoints {
+		if min, _ := dp.Min.Value(); min < 0.01 {
+			t.Errorf("got the min duration %vs, want at least the 10ms elapsed", min)
+		}
+		counts[dp.Attributes.Equivalent()] = dp.Count
+	}
+	for _, want := range []struct {
+		attrs attribute.Set
+		count uint64
+	}{
+		{attribute.NewSet(attribute.String("db.system", "mysql"), attribute.String("db.operation.name", "select"), attribute.String("db.collection.name", "users")), 2},
+		{attribute.NewSet(attribute.String("db.system", "mysql"), attribute.String("db.operation.name", "update"), attribute.String("db.collection.name", "orders")), 1},
+	} {
+		if got := counts[want.attrs.Equivalent()]; got != want.count {
+			t.Errorf("got %d durations with %v, want %d", got, want.attrs.ToSlice(), want.count)
+		}
+	}
+	if len(counts) != 2 {
+		t.Errorf("got %d duration attribute sets, want 2", len(counts))
+	}
+
+	errs, ok := metrics["db.client.operation.errors"].Data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("got %T, want the error counter", metrics["db.client.operation.errors"].Data)
+	}
+	if len(errs.DataPoints) != 1 || errs.DataPoints[0].Value != 1 {
+		t.Fatalf("got the error data points %v, want a single one counting 1", errs.DataPoints)
+	}
+	if system, _ := errs.DataPoints[0].Attributes.Value("db.system"); system.AsString() != "mysql" {
+		t.Errorf("got the error attributes %v, want db.system mysql", errs.DataPoints[0].Attributes.ToSlice())
+	}
+}
+
+func TestMetricsWithoutErrors(t *testing.T) {
+	metrics := collect(t, []func() (string, int64){
+		func() (string, int64) { return "SELECT 1", 1 },
+	}, []error{nil})
+
+	duration := metrics["db.client.operation.duration"].Data.(metricdata.Histogram[float64])
+	if len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 1 {
+		t.Errorf("got the duration data points %v, want a single one counting 1", duration.DataPoints)
+	}
+	if _, ok := duration.DataPoints[0].Attributes.Value("db.system"); ok {
+		t.Errorf("got the attributes %v, want no db.system without WithDBSystem", duration.DataPoints[0].Attributes.ToSlice())
+	}
+	if errs, ok := metrics["db.client.operation.errors"]; ok && len(errs.Data.(metricdata.Sum[int64]).DataPoints) != 0 {
+		t.Errorf("got the error data points %v, want none", errs.Data)
+	}
+}
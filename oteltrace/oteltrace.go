@@ -0,0 +1,31 @@
+// Package oteltrace provide the gorm_logrus option logging the OpenTelemetry trace and span ids
+// of the context, to correlate the sql logs with the spans, e.g. the otelgorm ones
+//
+//	gorm_logrus.New(oteltrace.WithTraceFields())
+package oteltrace
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	gorm_logrus "github.com/taotao2tingbao/gorm-logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ gorm_logrus.ContextExtractor = TraceFields
+
+// WithTraceFields log the trace_id and span_id fields of the span stored in the context of every call
+func WithTraceFields() gorm_logrus.Option {
+	return gorm_logrus.WithContextFields(TraceFields)
+}
+
+// TraceFields return the trace_id and span_id fields of the span context of ctx, none when it isn't valid
+func TraceFields(ctx context.Context) logrus.Fields {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return logrus.Fields{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	}
+}
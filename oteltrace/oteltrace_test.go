@@ -0,0 +1,42 @@
+package oteltrace
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	gorm_logrus "github.com/taotao2tingbao/gorm-logrus"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+	"time"
+)
+
+func TestWithTraceFields(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	log.SetLevel(logrus.TraceLevel)
+	l := gorm_logrus.New(gorm_logrus.WithLogger(log), WithTraceFields())
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	l.Info(ctx, "info message")
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	for _, entry := range hook.AllEntries() {
+		if entry.Data["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || entry.Data["span_id"] != "00f067aa0ba902b7" {
+			t.Errorf("got %q fields %v, want the trace and span ids", entry.Message, entry.Data)
+		}
+	}
+}
+
+func TestTraceFieldsWithoutSpan(t *testing.T) {
+	ctx := context.WithValue(context.Background(), struct{}{}, 1)
+	if fields := TraceFields(ctx); fields != nil {
+		t.Errorf("got %v, want no fields without a span", fields)
+	}
+	invalid := trace.ContextWithSpanContext(ctx, trace.SpanContext{})
+	if fields := TraceFields(invalid); fields != nil {
+		t.Errorf("got %v, want no fields for an invalid span context", fields)
+	}
+}
@@ -0,0 +1,148 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultPeriodicSummarySize = 10
+
+// WithPeriodicSummary log every interval a summary of the queries traced meanwhile,
+// with the slowest and most frequent fingerprints, until Stop is called
+func WithPeriodicSummary(interval time.Duration) Option {
+	return func(opt *options) {
+		opt.periodicInterval = interval
+	}
+}
+
+// WithPeriodicSummarySize set the number of fingerprints in each list of the periodic summary, default 10
+func WithPeriodicSummarySize(n int) Option {
+	return func(opt *options) {
+		opt.periodicSize = n
+	}
+}
+
+type (
+	// periodicSummary aggregate the queries per fingerprint between two summaries
+	periodicSummary struct {
+		mu      sync.Mutex
+		queries int
+		stats   map[string]*fingerprintStats
+		size    int
+		done    chan struct{}
+		once    sync.Once
+	}
+	fingerprintStats struct {
+		count int
+		total time.Duration
+		max   time.Duration
+	}
+)
+
+func newPeriodicSummary(interval time.Duration, size int, l *Logger) *periodicSummary {
+	if interval <= 0 {
+		return nil
+	}
+	if size <= 0 {
+		size = defaultPeriodicSummarySize
+	}
+	s := &periodicSummary{stats: map[string]*fingerprintStats{}, size: size, done: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.flush(l)
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// add record a traced query
+func (s *periodicSummary) add(t *traceCall) {
+	if s == nil {
+		return
+	}
+	fp := fingerprint(t.sql())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries++
+	stats, ok := s.stats[fp]
+	if !ok {
+		if len(s.stats) >= maxFingerprints {
+			return
+		}
+		stats = &fingerprintStats{}
+		s.stats[fp] = stats
+	}
+	stats.count++
+	stats.total += t.elapsed
+	if t.elapsed > stats.max {
+		stats.max = t.elapsed
+	}
+}
+
+// flush log the summary and reset it
+func (s *periodicSummary) flush(l *Logger) {
+	s.mu.Lock()
+	queries, stats := s.queries, s.stats
+	s.queries, s.stats = 0, map[string]*fingerprintStats{}
+	s.mu.Unlock()
+	if queries == 0 {
+		return
+	}
+
+	fps := make([]string, 0, len(stats))
+	for fp := range stats {
+		fps = append(fps, fp)
+	}
+	top := func(less func(a, b *fingerprintStats) bool) []map[string]interface{} {
+		sort.Slice(fps, func(i, j int) bool {
+			return less(stats[fps[i]], stats[fps[j]])
+		})
+		n := len(fps)
+		if n > s.size {
+			n = s.size
+		}
+		list := make([]map[string]interface{}, 0, n)
+		for _, fp := range fps[:n] {
+			list = append(list, map[string]interface{}{
+				"sql":     l.formatSQL(fp),
+				"count":   stats[fp].count,
+				"max_ms":  elapsedMs(stats[fp].max),
+				"mean_ms": elapsedMs(stats[fp].total) / float64(stats[fp].count),
+			})
+		}
+		return list
+	}
+	slowest := top(func(a, b *fingerprintStats) bool { return a.max > b.max })
+	frequent := top(func(a, b *fingerprintStats) bool { return a.count > b.count })
+	l.logf(context.Background(), logrus.InfoLevel, logrus.Fields{
+		"queries":  queries,
+		"slowest":  slowest,
+		"frequent": frequent,
+	}, "sql periodic summary")
+}
+
+// stop stop the summary goroutine
+func (s *periodicSummary) stop() {
+	if s != nil {
+		s.once.Do(func() { close(s.done) })
+	}
+}
+
+// Stop stop the background work of the logger, such as the periodic summary, the sampling and error
+// rate limit reports or the slow query EXPLAIN, safe to call several times
+func (l *Logger) Stop() {
+	l.periodic.stop()
+	l.sampler.stop()
+	l.errorLimiter.stop()
+	l.explainer.stop()
+}
@@ -0,0 +1,37 @@
+// Package pgerr provide gorm_logrus helpers for the errors of the pgx and pq postgres drivers
+//
+//	gorm_logrus.New(gorm_logrus.WithErrorCodeExtractors(pgerr.ErrorCode))
+package pgerr
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	gorm_logrus "github.com/taotao2tingbao/gorm-logrus"
+)
+
+var _ gorm_logrus.ErrorCodeExtractor = ErrorCode
+
+// lockNotAvailable SQLSTATE of the lock_timeout and NOWAIT failures
+const lockNotAvailable = "55P03"
+
+// ErrorCode return the SQLSTATE code of a *pgconn.PgError or *pq.Error, e.g. 23505 for a unique violation
+func ErrorCode(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code, true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code), true
+	}
+	return "", false
+}
+
+// IsLockTimeout report whether err is a lock not available error (55P03), to use with WithLockTimeoutMatcher
+//
+//	gorm_logrus.New(gorm_logrus.WithLockTimeoutMatcher(pgerr.IsLockTimeout))
+func IsLockTimeout(err error) bool {
+	code, ok := ErrorCode(err)
+	return ok && code == lockNotAvailable
+}
@@ -0,0 +1,86 @@
+package gorm_logrus
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"reflect"
+)
+
+type (
+	modelKey  struct{}
+	modelInfo struct {
+		model, table string
+	}
+)
+
+// ModelPlugin gorm plugin storing the statement model and table names in the context,
+// so that Trace can log them as the model and table fields, see RegisterModelContext
+//
+//	db.Use(gorm_logrus.ModelPlugin{})
+type ModelPlugin struct{}
+
+// RegisterModelContext register the ModelPlugin on db, right after gorm.Open and before the
+// statements whose model and table should be logged, its callbacks run before all the others
+func RegisterModelContext(db *gorm.DB) error {
+	return db.Use(ModelPlugin{})
+}
+
+// Name implements gorm.Plugin
+func (ModelPlugin) Name() string {
+	return "gorm_logrus:model"
+}
+
+// Initialize implements gorm.Plugin
+func (p ModelPlugin) Initialize(db *gorm.DB) error {
+	name := p.Name()
+	callback := db.Callback()
+	for _, err := range []error{
+		callback.Create().Before("*").Register(name, setModelContext),
+		callback.Query().Before("*").Register(name, setModelContext),
+		callback.Update().Before("*").Register(name, setModelContext),
+		callback.Delete().Before("*").Register(name, setModelContext),
+		callback.Row().Before("*").Register(name, setModelContext),
+		callback.Raw().Before("*").Register(name, setModelContext),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setModelContext(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt == nil || stmt.Context == nil {
+		return
+	}
+	info := modelInfo{model: modelName(stmt), table: stmt.Table}
+	if info.model != "" || info.table != "" {
+		stmt.Context = context.WithValue(stmt.Context, modelKey{}, info)
+	}
+}
+
+func modelName(stmt *gorm.Statement) string {
+	if stmt.Schema != nil {
+		return stmt.Schema.Name
+	}
+	if stmt.Model == nil {
+		return ""
+	}
+	t := reflect.TypeOf(stmt.Model)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+	return t.Name()
+}
+
+func modelFrom(ctx context.Context) modelInfo {
+	if ctx == nil {
+		return modelInfo{}
+	}
+	info, _ := ctx.Value(modelKey{}).(modelInfo)
+	return info
+}
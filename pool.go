@@ -0,0 +1,70 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+// WithPoolStatsLevel set the level used by StartPoolStatsLogger, default Info
+func WithPoolStatsLevel(level logrus.Level) Option {
+	return func(opt *options) {
+		opt.poolStatsLevel = &level
+	}
+}
+
+// StartPoolStatsLogger periodically log the connection pool stats of db,
+// through the logrus logger of db's Logger when it is one of ours, and
+// return a func stopping it
+func StartPoolStatsLogger(db *gorm.DB, interval time.Duration) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, errors.New("gorm logger: pool stats interval must be positive")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		backend ContextLogger = logrusBackend{log: logrus.StandardLogger()}
+		level                 = logrus.InfoLevel
+	)
+	if l, ok := db.Logger.(*Logger); ok {
+		backend = l.backend
+		if l.poolStatsLevel != nil {
+			level = *l.poolStatsLevel
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if !backend.Enabled(level) {
+					continue
+				}
+				stats := sqlDB.Stats()
+				backend.Log(context.Background(), level, logrus.Fields{
+					"open_connections": stats.OpenConnections,
+					"in_use":           stats.InUse,
+					"idle":             stats.Idle,
+					"wait_count":       stats.WaitCount,
+					"wait_duration":    stats.WaitDuration.String(),
+				}, "sql pool stats")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}, nil
+}
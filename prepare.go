@@ -0,0 +1,136 @@
+package gorm_logrus
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"sync"
+)
+
+type (
+	// prepareState whether the statement of a call was prepared by it, set by PreparePlugin
+	prepareState struct {
+		before   int
+		prepared bool
+	}
+	prepareStateKey struct{}
+
+	// prepareCounter count the prepares and executions per fingerprint, safe for concurrent use
+	prepareCounter struct {
+		mu     sync.Mutex
+		counts map[string]*prepareCount
+	}
+	prepareCount struct {
+		prepared, executed int
+	}
+)
+
+// PreparePlugin gorm plugin recording in the context whether a statement was newly prepared,
+// required by WithPrepareStats, it is only meaningful with the PrepareStmt mode, and best-effort
+// as statements prepared concurrently by other calls may be attributed to the current one
+//
+//	db.Use(gorm_logrus.PreparePlugin{})
+type PreparePlugin struct{}
+
+// Name implements gorm.Plugin
+func (PreparePlugin) Name() string {
+	return "gorm_logrus:prepare"
+}
+
+// Initialize implements gorm.Plugin
+func (p PreparePlugin) Initialize(db *gorm.DB) error {
+	before, after := p.Name()+":before", p.Name()+":after"
+	callback := db.Callback()
+	for _, err := range []error{
+		callback.Create().Before("*").Register(before, beforePrepare),
+		callback.Create().After("*").Register(after, afterPrepare),
+		callback.Query().Before("*").Register(before, beforePrepare),
+		callback.Query().After("*").Register(after, afterPrepare),
+		callback.Update().Before("*").Register(before, beforePrepare),
+		callback.Update().After("*").Register(after, afterPrepare),
+		callback.Delete().Before("*").Register(before, beforePrepare),
+		callback.Delete().After("*").Register(after, afterPrepare),
+		callback.Row().Before("*").Register(before, beforePrepare),
+		callback.Row().After("*").Register(after, afterPrepare),
+		callback.Raw().Before("*").Register(before, beforePrepare),
+		callback.Raw().After("*").Register(after, afterPrepare),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func preparedSQLCount(db *gorm.DB) (int, bool) {
+	var stmtDB *gorm.PreparedStmtDB
+	switch pool := db.Statement.ConnPool.(type) {
+	case *gorm.PreparedStmtDB:
+		stmtDB = pool
+	case *gorm.PreparedStmtTX:
+		stmtDB = pool.PreparedStmtDB
+	}
+	if stmtDB == nil {
+		return 0, false
+	}
+	stmtDB.Mux.RLock()
+	defer stmtDB.Mux.RUnlock()
+	return len(stmtDB.PreparedSQL), true
+}
+
+func beforePrepare(db *gorm.DB) {
+	if db.Statement == nil || db.Statement.Context == nil {
+		return
+	}
+	if n, ok := preparedSQLCount(db); ok {
+		db.Statement.Context = context.WithValue(db.Statement.Context, prepareStateKey{}, &prepareState{before: n})
+	}
+}
+
+func afterPrepare(db *gorm.DB) {
+	if db.Statement == nil || db.Statement.Context == nil {
+		return
+	}
+	if state, ok := db.Statement.Context.Value(prepareStateKey{}).(*prepareState); ok {
+		n, _ := preparedSQLCount(db)
+		state.prepared = n > state.before
+	}
+}
+
+// WithPrepareStats log the prepared and executed counts of the statement fingerprint,
+// requires the PreparePlugin to be registered
+func WithPrepareStats(enabled bool) Option {
+	return func(opt *options) {
+		opt.prepareCounts = nil
+		if enabled {
+			opt.prepareCounts = &prepareCounter{counts: map[string]*prepareCount{}}
+		}
+	}
+}
+
+// countPrepare count the call in the prepare stats of its fingerprint
+func (l *Logger) countPrepare(t *traceCall) {
+	if l.prepareCounts == nil || t.ctx == nil {
+		return
+	}
+	state, ok := t.ctx.Value(prepareStateKey{}).(*prepareState)
+	if !ok {
+		return
+	}
+	fp := fingerprint(t.sql())
+	c := l.prepareCounts
+	c.mu.Lock()
+	count, ok := c.counts[fp]
+	if !ok {
+		if len(c.counts) >= maxFingerprints {
+			c.counts = map[string]*prepareCount{}
+		}
+		count = &prepareCount{}
+		c.counts[fp] = count
+	}
+	count.executed++
+	if state.prepared {
+		count.prepared++
+	}
+	t.prepared, t.executed = count.prepared, count.executed
+	c.mu.Unlock()
+}
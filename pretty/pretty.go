@@ -0,0 +1,135 @@
+// Package pretty provide a simple sql pretty-printer for gorm_logrus.WithSQLFormatter,
+// meant for local development only
+package pretty
+
+import (
+	"strings"
+)
+
+// keywords upper cased by Format
+var keywords = map[string]bool{
+	"ADD": true, "ALL": true, "ALTER": true, "AND": true, "AS": true, "ASC": true,
+	"BETWEEN": true, "BY": true, "CASE": true, "COUNT": true, "CREATE": true, "CROSS": true,
+	"DELETE": true, "DESC": true, "DISTINCT": true, "DROP": true, "ELSE": true, "END": true,
+	"EXISTS": true, "FROM": true, "FULL": true, "GROUP": true, "HAVING": true, "IN": true,
+	"INDEX": true, "INNER": true, "INSERT": true, "INTO": true, "IS": true, "JOIN": true,
+	"LEFT": true, "LIKE": true, "LIMIT": true, "NOT": true, "NULL": true, "OFFSET": true,
+	"ON": true, "OR": true, "ORDER": true, "OUTER": true, "RETURNING": true, "RIGHT": true,
+	"SELECT": true, "SET": true, "TABLE": true, "THEN": true, "UNION": true, "UPDATE": true,
+	"VALUES": true, "WHEN": true, "WHERE": true, "WITH": true,
+}
+
+// clauses starting a new line, and the indent of the line
+var clauses = map[string]int{
+	"SELECT": 0, "FROM": 0, "WHERE": 0, "GROUP": 0, "HAVING": 0, "ORDER": 0,
+	"LIMIT": 0, "OFFSET": 0, "UNION": 0, "VALUES": 0, "SET": 0, "RETURNING": 0,
+	"INNER": 1, "LEFT": 1, "RIGHT": 1, "FULL": 1, "CROSS": 1, "JOIN": 1,
+	"AND": 1, "OR": 1,
+}
+
+// joinModifiers may precede JOIN, which then stays on their line
+var joinModifiers = map[string]bool{
+	"INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true, "OUTER": true,
+}
+
+// Format upper case the sql keywords and put each clause on its own line, the AND of a BETWEEN staying on
+// its line, quoted strings, identifiers and comments are left untouched
+func Format(sql string) string {
+	var (
+		b    strings.Builder
+		prev string
+		// space a whitespace run precedes the token, written unless the token starts a line
+		space, between, lineComment bool
+	)
+	b.Grow(len(sql) + len(sql)/8)
+	for _, tok := range tokenize(sql) {
+		if strings.TrimSpace(tok) == "" {
+			space = true
+			continue
+		}
+		// a line comment runs to the end of its line
+		newline, indent := lineComment, 0
+		if upper := strings.ToUpper(tok); keywords[upper] {
+			tok = upper
+			if i, ok := clauses[upper]; ok && !(upper == "JOIN" && joinModifiers[prev]) && !(upper == "AND" && between) {
+				newline, indent = true, i
+			}
+			switch upper {
+			case "BETWEEN":
+				between = true
+			case "AND":
+				between = false
+			}
+		}
+		switch {
+		case newline && b.Len() > 0:
+			b.WriteByte('\n')
+			b.WriteString(strings.Repeat("  ", indent))
+		case space && b.Len() > 0:
+			b.WriteByte(' ')
+		}
+		space, lineComment, prev = false, strings.HasPrefix(tok, "--"), tok
+		b.WriteString(tok)
+	}
+	return b.String()
+}
+
+// tokenize split sql into words, quoted strings, comments, whitespace runs and single symbols
+func tokenize(sql string) []string {
+	var tokens []string
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		j := i + 1
+		switch {
+		case strings.HasPrefix(sql[i:], "--"):
+			if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+				j = i + end
+			} else {
+				j = len(sql)
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				j = i + 2 + end + 2
+			} else {
+				j = len(sql)
+			}
+		case c == '\'' || c == '"' || c == '`':
+			for j < len(sql) {
+				if sql[j] == c {
+					if j+1 < len(sql) && sql[j+1] == c {
+						j += 2
+						continue
+					}
+					j++
+					break
+				}
+				if sql[j] == '\\' && c == '\'' {
+					j++
+				}
+				j++
+			}
+		case isSpace(c):
+			for j < len(sql) && isSpace(sql[j]) {
+				j++
+			}
+		case isWord(c):
+			for j < len(sql) && isWord(sql[j]) {
+				j++
+			}
+		}
+		if j > len(sql) {
+			j = len(sql)
+		}
+		tokens = append(tokens, sql[i:j])
+		i = j
+	}
+	return tokens
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isWord(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
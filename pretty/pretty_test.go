@@ -0,0 +1,66 @@
+package pretty
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "clauses",
+			sql:  "select id, name from users where age > 18 and active = true order by id limit 10",
+			want: "SELECT id, name\nFROM users\nWHERE age > 18\n  AND active = true\nORDER BY id\nLIMIT 10",
+		},
+		{
+			name: "join",
+			sql:  "SELECT * FROM users u left join orders o ON o.user_id = u.id",
+			want: "SELECT *\nFROM users u\n  LEFT JOIN orders o ON o.user_id = u.id",
+		},
+		{
+			name: "quoted keywords",
+			sql:  `SELECT 'select from where' AS s, "order" FROM ` + "`group`" + ` WHERE name = 'it''s and or'`,
+			want: "SELECT 'select from where' AS s, \"order\"\nFROM `group`\nWHERE name = 'it''s and or'",
+		},
+		{
+			name: "escaped quote",
+			sql:  `SELECT * FROM users WHERE name = 'a\' from b'`,
+			want: "SELECT *\nFROM users\nWHERE name = 'a\\' from b'",
+		},
+		{
+			name: "between",
+			sql:  "SELECT * FROM users WHERE age between 18 and 65 and active = true",
+			want: "SELECT *\nFROM users\nWHERE age BETWEEN 18 AND 65\n  AND active = true",
+		},
+		{
+			name: "line comment",
+			sql:  "SELECT id -- the where clause or not\nFROM users",
+			want: "SELECT id -- the where clause or not\nFROM users",
+		},
+		{
+			name: "line comment before a plain token",
+			sql:  "SELECT id, -- from users\n name FROM users",
+			want: "SELECT id, -- from users\nname\nFROM users",
+		},
+		{
+			name: "block comment",
+			sql:  "SELECT /* select from where */ id FROM users",
+			want: "SELECT /* select from where */ id\nFROM users",
+		},
+		{
+			name: "whitespace",
+			sql:  "  SELECT\t1\n\n FROM  dual  ",
+			want: "SELECT 1\nFROM dual",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Format(tt.sql); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package gorm_logrus
+
+import (
+	"gorm.io/gorm/logger"
+	"time"
+)
+
+// profiles preset by WithProfile
+var profiles = map[string]func(opt *options){
+	// dev log every query, unsampled, with its caller
+	"dev": func(opt *options) {
+		opt.cfg.LogLevel = logger.Info
+		opt.cfg.SlowThreshold = 200 * time.Millisecond
+		opt.samplingRate = 0
+		opt.noCaller = false
+	},
+	// staging log slow queries and errors, with their caller
+	"staging": func(opt *options) {
+		opt.cfg.LogLevel = logger.Warn
+		opt.cfg.SlowThreshold = 200 * time.Millisecond
+		opt.cfg.IgnoreRecordNotFoundError = true
+		opt.samplingRate = 0
+		opt.noCaller = false
+	},
+	// prod log errors only, the successful queries being sampled at 10% when the level is raised,
+	// without the stack walk of the caller
+	"prod": func(opt *options) {
+		opt.cfg.LogLevel = logger.Error
+		opt.cfg.SlowThreshold = time.Second
+		opt.cfg.IgnoreRecordNotFoundError = true
+		opt.samplingRate = 0.1
+		opt.noCaller = true
+	},
+	// loki shape the output for Loki: the low cardinality operation and table fields can be used as
+	// labels, while the high cardinality sql stays in the message, labels being indexed per value
+	"loki": func(opt *options) {
+		opt.operationField = true
+		opt.tableField = true
+		opt.queryMessage, opt.slowMessage, opt.errorMessage = "", "", ""
+		opt.nestedField = ""
+	},
+}
+
+// WithProfile preset the options of a built-in profile: "dev", "staging", "prod" or "loki",
+// options given after it override the preset, unknown profiles are ignored
+func WithProfile(name string) Option {
+	return func(opt *options) {
+		if profile, ok := profiles[name]; ok {
+			profile(opt)
+		}
+	}
+}
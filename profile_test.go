@@ -0,0 +1,38 @@
+package gorm_logrus
+
+import (
+	"gorm.io/gorm/logger"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	tests := []struct {
+		profile  string
+		level    logger.LogLevel
+		sampling float64
+		noCaller bool
+	}{
+		{profile: "dev", level: logger.Info},
+		{profile: "staging", level: logger.Warn},
+		{profile: "prod", level: logger.Error, sampling: 0.1, noCaller: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			l, _ := newTestLogger(WithProfile(tt.profile))
+			if l.cfg.LogLevel != tt.level || l.samplingRate != tt.sampling || l.noCaller != tt.noCaller {
+				t.Errorf("got level %v, sampling %v and no caller %v, want %v, %v and %v",
+					l.cfg.LogLevel, l.samplingRate, l.noCaller, tt.level, tt.sampling, tt.noCaller)
+			}
+		})
+	}
+}
+
+func TestProfileOverriddenByLaterOptions(t *testing.T) {
+	l, _ := newTestLogger(WithProfile("prod"), WithSampling(0.5))
+	if l.samplingRate != 0.5 || !l.noCaller {
+		t.Errorf("got sampling %v and no caller %v, want the later sampling over the prod preset", l.samplingRate, l.noCaller)
+	}
+	if l, _ = newTestLogger(WithoutCaller(), WithProfile("dev")); l.noCaller {
+		t.Error("got no caller, want the dev preset to keep the caller")
+	}
+}
@@ -0,0 +1,33 @@
+package gorm_logrus
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+)
+
+var (
+	queryIDPrefix  = newQueryIDPrefix()
+	queryIDCounter uint64
+)
+
+// WithQueryIDField log a short id unique to each traced query as the query_id field
+func WithQueryIDField(enabled bool) Option {
+	return func(opt *options) {
+		opt.queryIDField = enabled
+	}
+}
+
+// newQueryID return a process random prefix followed by a counter
+func newQueryID() string {
+	return queryIDPrefix + strconv.FormatUint(atomic.AddUint64(&queryIDCounter, 1), 36)
+}
+
+func newQueryIDPrefix() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "q-"
+	}
+	return hex.EncodeToString(b) + "-"
+}
@@ -0,0 +1,142 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// errorRateLimitReportInterval the interval between the reports of the error logs suppressed by the rate limit
+const errorRateLimitReportInterval = time.Second
+
+// WithErrorRateLimit cap the failed query logs to perSecond, with bursts of up to perSecond,
+// the number of suppressed logs is reported by a warning every second, or once logging resumes
+func WithErrorRateLimit(perSecond int) Option {
+	return func(opt *options) {
+		opt.errorLimiter = newTokenBucket(perSecond)
+	}
+}
+
+// tokenBucket a token bucket rate limiter counting the denied calls, safe for concurrent use
+type tokenBucket struct {
+	mu         sync.Mutex
+	rate       float64
+	tokens     float64
+	last       time.Time
+	suppressed int
+	done       chan struct{}
+	once       sync.Once
+}
+
+func newTokenBucket(perSecond int) *tokenBucket {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &tokenBucket{rate: float64(perSecond), tokens: float64(perSecond), last: time.Now()}
+}
+
+// allow take a token, returning false when none is left, and the number of calls
+// denied since the last allowed one
+func (b *tokenBucket) allow() (bool, int) {
+	if b == nil {
+		return true, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+	if b.tokens < 1 {
+		b.suppressed++
+		return false, 0
+	}
+	b.tokens--
+	suppressed := b.suppressed
+	b.suppressed = 0
+	return true, suppressed
+}
+
+// report log every interval the error logs suppressed meanwhile to the error branch backend, until stop is called
+func (b *tokenBucket) report(l *Logger, interval time.Duration) {
+	if b == nil {
+		return
+	}
+	b.done = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.mu.Lock()
+				suppressed := b.suppressed
+				b.suppressed = 0
+				b.mu.Unlock()
+				if suppressed > 0 {
+					l.logTo(l.branchBackend(BranchError), context.Background(), logrus.WarnLevel, logrus.Fields{"suppressed": suppressed},
+						"%d sql error logs suppressed by the rate limit", suppressed)
+				}
+			case <-b.done:
+				return
+			}
+		}
+	}()
+}
+
+// stop stop the report goroutine
+func (b *tokenBucket) stop() {
+	if b != nil && b.done != nil {
+		b.once.Do(func() { close(b.done) })
+	}
+}
+
+// WithRateLimit cap the successful query logs to n per window, errors and slow queries are never limited,
+// the number of suppressed logs of a window is reported by a single line with the first log of a later one
+func WithRateLimit(n int, per time.Duration) Option {
+	return func(opt *options) {
+		opt.queryLimiter = newWindowLimiter(n, per)
+	}
+}
+
+// windowLimiter a fixed window rate limiter counting the denied calls, safe for concurrent use
+type windowLimiter struct {
+	mu         sync.Mutex
+	limit      int
+	window     time.Duration
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+func newWindowLimiter(n int, per time.Duration) *windowLimiter {
+	if n <= 0 || per <= 0 {
+		return nil
+	}
+	return &windowLimiter{limit: n, window: per, start: time.Now()}
+}
+
+// allow count a call in the current window, returning false once the limit is reached, and
+// the number of calls denied in the previous windows on the first call of a new one
+func (w *windowLimiter) allow() (bool, int) {
+	if w == nil {
+		return true, 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var suppressed int
+	if now := time.Now(); now.Sub(w.start) >= w.window {
+		w.start = now
+		w.count = 0
+		suppressed, w.suppressed = w.suppressed, 0
+	}
+	if w.count >= w.limit {
+		w.suppressed++
+		return false, suppressed
+	}
+	w.count++
+	return true, suppressed
+}
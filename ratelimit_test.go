@@ -0,0 +1,45 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"testing"
+	"time"
+)
+
+func TestErrorRateLimitReportsSuppressedPeriodically(t *testing.T) {
+	l, hook := newTestLogger(WithErrorRateLimit(1))
+	defer l.Stop()
+	for i := 0; i < 4; i++ {
+		l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 0 }, errors.New("failed"))
+	}
+	if n := len(hook.AllEntries()); n != 1 {
+		t.Fatalf("got %d entries, want the first error only", n)
+	}
+
+	// no later error is logged, the report comes from the ticker
+	deadline := time.Now().Add(3 * errorRateLimitReportInterval)
+	for len(hook.AllEntries()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	entries := hook.AllEntries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want the error and the suppressed report", len(entries))
+	}
+	if report := entries[1]; report.Level != logrus.WarnLevel || report.Data["suppressed"] != 3 {
+		t.Errorf("got the report %s %v, want Warn with 3 suppressed", report.Level, report.Data)
+	}
+}
+
+func TestErrorRateLimitStop(t *testing.T) {
+	l, hook := newTestLogger(WithErrorRateLimit(1))
+	l.Stop()
+	for i := 0; i < 2; i++ {
+		l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 0 }, errors.New("failed"))
+	}
+	time.Sleep(errorRateLimitReportInterval + 100*time.Millisecond)
+	if n := len(hook.AllEntries()); n != 1 {
+		t.Errorf("got %d entries, want no report once stopped", n)
+	}
+}
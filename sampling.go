@@ -0,0 +1,112 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// WithSampling log only a rate fraction of the successful queries, errors and slow queries
+// are always logged, a rate outside of (0, 1) disables the sampling, the queries are sampled
+// once the level, filters, WithSuppressZeroRows and WithRateLimit let them through
+func WithSampling(rate float64) Option {
+	return func(opt *options) {
+		opt.samplingRate = rate
+	}
+}
+
+// WithSamplingSource set the random source of the sampling, for reproducible sampling,
+// default to a time seeded source
+func WithSamplingSource(source rand.Source) Option {
+	return func(opt *options) {
+		opt.samplingSource = source
+	}
+}
+
+// WithSampledField tag the successful queries kept by the sampling with the sampled field
+func WithSampledField(enabled bool) Option {
+	return func(opt *options) {
+		opt.sampledField = enabled
+	}
+}
+
+// WithSamplingReport log every interval the number of successful queries dropped by the sampling
+// meanwhile, until Stop is called, the sampled entries plus the reported drops add up to the
+// successful queries that would have been logged without sampling
+func WithSamplingReport(interval time.Duration) Option {
+	return func(opt *options) {
+		opt.samplingReportInterval = interval
+	}
+}
+
+// sampler probabilistic sampler, safe for concurrent use
+type sampler struct {
+	mu      sync.Mutex
+	rand    *rand.Rand
+	rate    float64
+	dropped int
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newSampler(rate float64, source rand.Source) *sampler {
+	if rate <= 0 || rate >= 1 {
+		return nil
+	}
+	if source == nil {
+		source = rand.NewSource(time.Now().UnixNano())
+	}
+	return &sampler{rand: rand.New(source), rate: rate}
+}
+
+// sample report whether the query should be logged
+func (s *sampler) sample() bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rand.Float64() < s.rate {
+		return true
+	}
+	s.dropped++
+	return false
+}
+
+// report log every interval the queries dropped meanwhile, until stop is called
+func (s *sampler) report(l *Logger, interval time.Duration) {
+	if s == nil || interval <= 0 {
+		return
+	}
+	s.done = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.mu.Lock()
+				dropped := s.dropped
+				s.dropped = 0
+				s.mu.Unlock()
+				if dropped > 0 {
+					l.logf(context.Background(), logrus.InfoLevel, logrus.Fields{
+						"dropped":     dropped,
+						"sample_rate": s.rate,
+					}, "%d sql logs dropped by the sampling", dropped)
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// stop stop the report goroutine
+func (s *sampler) stop() {
+	if s != nil && s.done != nil {
+		s.once.Do(func() { close(s.done) })
+	}
+}
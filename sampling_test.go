@@ -0,0 +1,96 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSamplingCountsOnlyLoggableQueries(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		level logrus.Level
+		sql   string
+		rows  int64
+	}{
+		{name: "level disabled", level: logrus.InfoLevel, sql: "SELECT 1", rows: 1},
+		{name: "excluded", opts: []Option{WithExcludeSQL("^SELECT")}, level: logrus.TraceLevel, sql: "SELECT 1", rows: 1},
+		{name: "zero rows suppressed", opts: []Option{WithSuppressZeroRows("insert")}, level: logrus.TraceLevel, sql: "INSERT INTO t VALUES (1)"},
+		{name: "rate limited", opts: []Option{WithRateLimit(1, time.Hour)}, level: logrus.TraceLevel, sql: "SELECT 1", rows: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, hook := test.NewNullLogger()
+			log.SetLevel(tt.level)
+			l := New(append(tt.opts, WithLogger(log), WithSampling(0.5), WithSamplingSource(rand.NewSource(1)))...).(*Logger)
+			for i := 0; i < 20; i++ {
+				l.Trace(context.Background(), time.Now(), func() (string, int64) { return tt.sql, tt.rows }, nil)
+			}
+			logged := 0
+			for _, entry := range hook.AllEntries() {
+				if entry.Message != "" && entry.Data["suppressed"] == nil {
+					logged++
+				}
+			}
+			if dropped := l.sampler.dropped; logged+dropped > 1 {
+				t.Errorf("got %d logged and %d dropped by the sampling, want the queries skipped before the sampling", logged, dropped)
+			}
+		})
+	}
+}
+
+func TestSamplingReportAddsUp(t *testing.T) {
+	l, hook := newTestLogger(WithSampling(0.5), WithSamplingSource(rand.NewSource(1)))
+	for i := 0; i < 100; i++ {
+		l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	}
+	if logged := len(hook.AllEntries()); logged+l.sampler.dropped != 100 || logged == 0 || logged == 100 {
+		t.Errorf("got %d logged and %d dropped, want a sample adding up to the 100 queries", logged, l.sampler.dropped)
+	}
+}
+
+func TestSamplingAndRateLimitConcurrent(t *testing.T) {
+	l, hook := newTestLogger(WithSampling(0.5), WithRateLimit(10, time.Hour), WithSlowThreshold(time.Second))
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+			}
+			l.Trace(ctx, slowBegin(time.Second), func() (string, int64) { return "SELECT 'slow'", 1 }, nil)
+			l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 'error'", 0 }, errors.New("failed"))
+		}()
+	}
+	wg.Wait()
+
+	counts := map[logrus.Level]int{}
+	for _, entry := range hook.AllEntries() {
+		counts[entry.Level]++
+	}
+	if n := counts[logrus.DebugLevel]; n == 0 || n > 10 || counts[logrus.WarnLevel] != 8 || counts[logrus.ErrorLevel] != 8 {
+		t.Errorf("got %v, want a sample of the 10 rate limited queries and every slow query and error", counts)
+	}
+}
+
+func TestRateLimitReportsSuppressedOnNextWindow(t *testing.T) {
+	l, hook := newTestLogger(WithRateLimit(1, 50*time.Millisecond))
+	for i := 0; i < 3; i++ {
+		l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	}
+	time.Sleep(60 * time.Millisecond)
+	l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	entries := hook.AllEntries()
+	if len(entries) != 3 || entries[1].Level != logrus.WarnLevel || entries[1].Data["suppressed"] != 2 {
+		t.Fatalf("got %d entries, want the first query, the report of the 2 suppressed ones and the next query", len(entries))
+	}
+}
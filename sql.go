@@ -0,0 +1,151 @@
+package gorm_logrus
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithSanitizeSQL escape the control characters of the logged sql, tabs and newlines are kept
+func WithSanitizeSQL(sanitize bool) Option {
+	return func(opt *options) {
+		opt.sanitizeSQL = sanitize
+	}
+}
+
+// WithSQLFormatter format the logged sql with formatter, e.g. pretty.Format for local development
+func WithSQLFormatter(formatter func(sql string) string) Option {
+	return func(opt *options) {
+		opt.sqlFormatter = formatter
+	}
+}
+
+// SQLMode how much of the sql is logged
+type SQLMode int
+
+const (
+	// SQLModeFull log the full sql
+	SQLModeFull SQLMode = iota
+	// SQLModeFingerprintOnly log the sql fingerprint, with its literals replaced by ?
+	SQLModeFingerprintOnly
+	// SQLModeNone don't log the sql
+	SQLModeNone
+)
+
+// WithSQLMode set how much of the sql is logged, default SQLModeFull
+func WithSQLMode(mode SQLMode) Option {
+	return func(opt *options) {
+		opt.sqlMode = mode
+	}
+}
+
+// WithParameterizedQueries log the sql with its placeholders instead of the bound values,
+// like the ParameterizedQueries field of the config, see ParamsFilter
+func WithParameterizedQueries(enabled bool) Option {
+	return func(opt *options) {
+		opt.parameterizedQueries = enabled
+	}
+}
+
+var _ gorm.ParamsFilter = (*Logger)(nil)
+
+// ParamsFilter implements gorm.ParamsFilter, dropping the params of the sql given to Trace
+// when WithParameterizedQueries or the ParameterizedQueries field of the config is set
+func (l *Logger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.parameterized() {
+		return sql, nil
+	}
+	return sql, params
+}
+
+// parameterized report whether the sql is logged with its placeholders
+func (l *Logger) parameterized() bool {
+	return l.parameterizedQueries || l.cfg.ParameterizedQueries
+}
+
+// WithSQLRedactor set a func scrubbing the logged sql, e.g. the string literals of drivers
+// interpolating the values before Trace gets the sql, applied before the other transforms
+func WithSQLRedactor(redactor func(sql string) string) Option {
+	return func(opt *options) {
+		opt.sqlRedactor = redactor
+	}
+}
+
+// WithMaxSQLLength truncate the logged sql to n runes, the "... (truncated, N bytes total)" suffix included, tagging
+// the entry with the sql_truncated field, applied after the other transforms, zero or negative n meaning no limit,
+// the sql is cut without the suffix when n is too small to hold it
+func WithMaxSQLLength(n int) Option {
+	return func(opt *options) {
+		opt.maxSQLLength = n
+	}
+}
+
+// truncateSQL return sql cut to the max length at a rune boundary, and whether it was cut
+func (l *Logger) truncateSQL(sql string) (string, bool) {
+	if l.maxSQLLength <= 0 || len(sql) <= l.maxSQLLength || utf8.RuneCountInString(sql) <= l.maxSQLLength {
+		return sql, false
+	}
+	suffix := fmt.Sprintf("... (truncated, %d bytes total)", len(sql))
+	keep := l.maxSQLLength - len(suffix)
+	if keep < 0 {
+		return sql[:runeOffset(sql, l.maxSQLLength)], true
+	}
+	return sql[:runeOffset(sql, keep)] + suffix, true
+}
+
+// runeOffset return the byte offset of the rune n of s, len(s) when s is shorter
+func runeOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
+
+// formatSQL apply the sql transforms in order: sql mode, redactor, redact, sanitize, formatter
+func (l *Logger) formatSQL(sql string) string {
+	switch l.sqlMode {
+	case SQLModeFingerprintOnly:
+		sql = fingerprint(sql)
+	case SQLModeNone:
+		return ""
+	}
+	if l.sqlRedactor != nil {
+		sql = l.sqlRedactor(sql)
+	}
+	if len(l.redactPositions) > 0 {
+		sql = redactLiterals(sql, l.redactPositions)
+	}
+	if l.sanitizeSQL {
+		sql = sanitizeSQL(sql)
+	}
+	if l.sqlFormatter != nil {
+		sql = l.sqlFormatter(sql)
+	}
+	return sql
+}
+
+func sanitizeSQL(sql string) string {
+	if strings.IndexFunc(sql, isControl) < 0 {
+		return sql
+	}
+	var b strings.Builder
+	b.Grow(len(sql))
+	for _, r := range sql {
+		if isControl(r) {
+			fmt.Fprintf(&b, "\\x%02x", r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+}
@@ -0,0 +1,84 @@
+package gorm_logrus
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm/logger"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParameterizedQueries(t *testing.T) {
+	const email = "alice@example.com"
+	tests := []struct {
+		name        string
+		opt         Option
+		dollar      bool
+		placeholder string
+	}{
+		{name: "option ?", opt: WithParameterizedQueries(true), placeholder: "?"},
+		{name: "option $1", opt: WithParameterizedQueries(true), dollar: true, placeholder: "$1"},
+		{name: "config", opt: WithConfig(logger.Config{ParameterizedQueries: true}), placeholder: "?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, hook := newTestLogger(tt.opt, WithQueryMessage("sql"))
+			db, mock := openTestDB(t, l, tt.dollar)
+			mock.ExpectExec(`INSERT INTO "test_users"`).WillReturnResult(sqlmock.NewResult(1, 1))
+			if err := db.Create(&testUser{Email: email}).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			entry := hook.LastEntry()
+			if entry == nil {
+				t.Fatal("the INSERT isn't logged")
+			}
+			// gorm's ExplainSQL renders an unbound $1 as $1$
+			sql, _ := entry.Data["sql"].(string)
+			if strings.Contains(sql, email) || !strings.Contains(sql, "VALUES ("+tt.placeholder) {
+				t.Errorf("got sql %q, want the %s placeholder instead of the email", sql, tt.placeholder)
+			}
+		})
+	}
+}
+
+func TestParameterizedQueriesDisabled(t *testing.T) {
+	l, hook := newTestLogger(WithQueryMessage("sql"))
+	db, mock := openTestDB(t, l, false)
+	mock.ExpectExec(`INSERT INTO "test_users"`).WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := db.Create(&testUser{Email: "alice@example.com"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if sql, _ := hook.LastEntry().Data["sql"].(string); !strings.Contains(sql, "'alice@example.com'") {
+		t.Errorf("got sql %q, want the bound email", sql)
+	}
+}
+
+func TestMaxSQLLength(t *testing.T) {
+	long := "SELECT * FROM `users` WHERE `name` = '" + strings.Repeat("é", 60) + "'"
+	tests := []struct {
+		name      string
+		n         int
+		sql       string
+		truncated bool
+		want      string
+	}{
+		{name: "within limit", n: 100, sql: "SELECT 1", want: "SELECT 1"},
+		{name: "multi-byte within limit", n: 100, sql: "SELECT '" + strings.Repeat("é", 60) + "'", want: "SELECT '" + strings.Repeat("é", 60) + "'"},
+		{name: "suffix reserved", n: 60, sql: long, truncated: true, want: "SELECT * FROM `users` WHERE ... (truncated, 159 bytes total)"},
+		{name: "cut at a rune boundary", n: 72, sql: long, truncated: true, want: "SELECT * FROM `users` WHERE `name` = 'éé... (truncated, 159 bytes total)"},
+		{name: "no room for the suffix", n: 10, sql: long, truncated: true, want: "SELECT * F"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLogger(WithMaxSQLLength(tt.n))
+			got, truncated := l.truncateSQL(tt.sql)
+			if got != tt.want || truncated != tt.truncated {
+				t.Errorf("got %q, %v, want %q, %v", got, truncated, tt.want, tt.truncated)
+			}
+			if !utf8.ValidString(got) || utf8.RuneCountInString(got) > tt.n {
+				t.Errorf("got %q, want valid UTF-8 of at most %d runes", got, tt.n)
+			}
+		})
+	}
+}
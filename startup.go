@@ -0,0 +1,27 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// LogStartup log an Info entry summarizing the logger configuration of db and its driver,
+// a marker of when and how the logger was set up
+func LogStartup(db *gorm.DB) {
+	driver := ""
+	if db.Dialector != nil {
+		driver = db.Dialector.Name()
+	}
+	l, ok := db.Logger.(*Logger)
+	if !ok {
+		db.Logger.Info(context.Background(), "gorm logger initialized, driver %s", driver)
+		return
+	}
+	cfg := l.config()
+	l.logf(context.Background(), logrus.InfoLevel, logrus.Fields{
+		"driver":         driver,
+		"log_level":      int(cfg.LogLevel),
+		"slow_threshold": cfg.SlowThreshold.String(),
+	}, "gorm logger initialized")
+}
@@ -0,0 +1,95 @@
+package gorm_logrus
+
+import (
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// WithSplitStatements log each statement of a multi-statement sql as its own entry with its
+// statement_index field, successful queries only, the split is best-effort: semicolons inside
+// quoted strings, quoted identifiers and comments don't end a statement
+func WithSplitStatements(enabled bool) Option {
+	return func(opt *options) {
+		opt.splitStatements = enabled
+	}
+}
+
+// splitStatements return the trimmed non-empty statements of sql
+func splitStatements(sql string) []string {
+	var (
+		statements []string
+		start      int
+	)
+	add := func(end int) {
+		if statement := strings.TrimSpace(sql[start:end]); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(sql, i)
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				i = len(sql)
+			} else {
+				i += j + 1
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			j := strings.Index(sql[i+2:], "*/")
+			if j < 0 {
+				i = len(sql)
+			} else {
+				i += j + 4
+			}
+		case c == ';':
+			add(i)
+			i++
+			start = i
+		default:
+			i++
+		}
+	}
+	add(len(sql))
+	return statements
+}
+
+// skipQuoted return the index following the quoted string starting at i,
+// doubled quotes and backslash escapes don't close it
+func skipQuoted(sql string, i int) int {
+	quote := sql[i]
+	for j := i + 1; j < len(sql); j++ {
+		switch sql[j] {
+		case '\\':
+			if quote == '\'' {
+				j++
+			}
+		case quote:
+			if j+1 < len(sql) && sql[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(sql)
+}
+
+// traceStatements log each statement of sql, see WithSplitStatements
+func (l *Logger) traceStatements(t *traceCall, level logrus.Level, fields logrus.Fields, sql string, rows int64) {
+	statements := splitStatements(sql)
+	if len(statements) < 2 {
+		l.logTrace(l.branchBackend(BranchQuery), t.ctx, level, fields, l.queryMessage, t.elapsed, l.formatSQL(sql), rows)
+		return
+	}
+	for i, statement := range statements {
+		statementFields := make(logrus.Fields, len(fields)+1)
+		for k, v := range fields {
+			statementFields[k] = v
+		}
+		statementFields["statement_index"] = i
+		l.logTrace(l.branchBackend(BranchQuery), t.ctx, level, statementFields, l.queryMessage, t.elapsed, l.formatSQL(statement), rows)
+	}
+}
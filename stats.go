@@ -0,0 +1,45 @@
+package gorm_logrus
+
+import (
+	"sync/atomic"
+)
+
+// Stats running totals of the traced queries, see Logger.Stats
+type Stats struct {
+	Queries uint64
+	Errors  uint64
+	// Slow the queries slower than the SlowThreshold or CallSlowThreshold, the operation thresholds
+	// and the slow threshold func are ignored, as they need the sql which isn't built for the totals
+	Slow uint64
+}
+
+// counters the atomic counters behind Stats, shared by the LogMode copies
+type counters struct {
+	queries, errors, slow uint64
+}
+
+// Stats return the totals of the queries traced since New or the last ResetStats, at any log level, Silent included
+func (l *Logger) Stats() Stats {
+	return Stats{
+		Queries: atomic.LoadUint64(&l.counters.queries),
+		Errors:  atomic.LoadUint64(&l.counters.errors),
+		Slow:    atomic.LoadUint64(&l.counters.slow),
+	}
+}
+
+// ResetStats reset the totals returned by Stats
+func (l *Logger) ResetStats() {
+	atomic.StoreUint64(&l.counters.queries, 0)
+	atomic.StoreUint64(&l.counters.errors, 0)
+	atomic.StoreUint64(&l.counters.slow, 0)
+}
+
+func (c *counters) count(failed, slow bool) {
+	atomic.AddUint64(&c.queries, 1)
+	if failed {
+		atomic.AddUint64(&c.errors, 1)
+	}
+	if slow {
+		atomic.AddUint64(&c.slow, 1)
+	}
+}
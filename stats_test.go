@@ -0,0 +1,29 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm/logger"
+	"testing"
+	"time"
+)
+
+func TestStatsCountedAtEveryLevel(t *testing.T) {
+	for _, level := range []logger.LogLevel{logger.Silent, logger.Error, logger.Warn, logger.Info} {
+		t.Run(levelName(level), func(t *testing.T) {
+			l, _ := newTestLogger(WithSlowThreshold(time.Second))
+			lm := l.LogMode(level)
+			ctx := context.Background()
+			lm.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+			lm.Trace(ctx, slowBegin(time.Second), func() (string, int64) { return "SELECT 2", 1 }, nil)
+			lm.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 3", 0 }, errors.New("failed"))
+			if got, want := l.Stats(), (Stats{Queries: 3, Errors: 1, Slow: 1}); got != want {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func levelName(level logger.LogLevel) string {
+	return map[logger.LogLevel]string{logger.Silent: "silent", logger.Error: "error", logger.Warn: "warn", logger.Info: "info"}[level]
+}
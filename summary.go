@@ -0,0 +1,104 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+type (
+	requestSummary struct {
+		mu           sync.Mutex
+		stats        requestStats
+		fingerprints *fingerprintCounter
+		errors       int
+	}
+	requestStats struct {
+		// logger the Logger of the last query, emitting the summary
+		logger     *Logger
+		queries    int
+		total      time.Duration
+		slowest    time.Duration
+		slowestSQL string
+	}
+	requestSummaryKey struct{}
+)
+
+// WithSummaryOnly suppress the per query debug logs of queries traced with a
+// request summary context, errors and slow queries are still logged
+func WithSummaryOnly(summaryOnly bool) Option {
+	return func(opt *options) {
+		opt.summaryOnly = summaryOnly
+	}
+}
+
+// WithRequestSummary return a copy of ctx accumulating the stats of the
+// queries traced with it, until FlushRequestSummary is called,
+// it also scopes the N+1 detection of WithNPlusOneDetection and WithFirstErrorSQLOnly
+func WithRequestSummary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestSummaryKey{}, &requestSummary{fingerprints: newFingerprintCounter()})
+}
+
+// WithFirstErrorSQLOnly omit the sql of the failed queries following the first one
+// within a single WithRequestSummary context, to keep error cascades readable
+func WithFirstErrorSQLOnly(enabled bool) Option {
+	return func(opt *options) {
+		opt.firstErrorSQLOnly = enabled
+	}
+}
+
+// FlushRequestSummary log a single entry summarizing the queries traced with ctx since WithRequestSummary,
+// shaped and truncated like the trace entries by the Logger of the last query, and reset the summary
+func FlushRequestSummary(ctx context.Context) {
+	summary := requestSummaryFrom(ctx)
+	if summary == nil {
+		return
+	}
+	summary.mu.Lock()
+	s := summary.stats
+	summary.stats = requestStats{}
+	summary.mu.Unlock()
+	if s.queries == 0 || !enabled(s.logger.backend, ctx, logrus.InfoLevel) {
+		return
+	}
+	sql, truncated := s.logger.truncateSQL(s.slowestSQL)
+	fields := logrus.Fields{
+		"queries":     s.queries,
+		"total_ms":    elapsedMs(s.total),
+		"slowest_ms":  elapsedMs(s.slowest),
+		"slowest_sql": sql,
+	}
+	if truncated {
+		fields["sql_truncated"] = true
+	}
+	s.logger.logTraceEntry(s.logger.backend, ctx, logrus.InfoLevel, fields, "", "sql request summary")
+}
+
+func requestSummaryFrom(ctx context.Context) *requestSummary {
+	if ctx == nil {
+		return nil
+	}
+	summary, _ := ctx.Value(requestSummaryKey{}).(*requestSummary)
+	return summary
+}
+
+func (s *requestSummary) add(l *Logger, elapsed time.Duration, sql func() string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.logger = l
+	s.stats.queries++
+	s.stats.total += elapsed
+	if s.stats.queries == 1 || elapsed > s.stats.slowest {
+		s.stats.slowest = elapsed
+		s.stats.slowestSQL = sql()
+	}
+}
+
+// addError count a failed query and return the number of failed queries before it
+func (s *requestSummary) addError() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors++
+	return s.errors - 1
+}
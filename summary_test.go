@@ -0,0 +1,53 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlushRequestSummaryShapedLikeTraces(t *testing.T) {
+	l, hook := newTestLogger(WithSummaryOnly(true), WithStaticFields(logrus.Fields{"service": "api"}),
+		WithNestedField("gorm"), WithMaxSQLLength(40))
+	ctx := WithFields(WithRequestSummary(context.Background()), logrus.Fields{"request_id": "r1"})
+	long := "SELECT * FROM users WHERE name = '" + strings.Repeat("x", 100) + "'"
+	fc, _ := countingTrace("SELECT 1", 1)
+	l.Trace(ctx, time.Now(), fc, nil)
+	fc, _ = countingTrace(long, 1)
+	l.Trace(ctx, time.Now().Add(-time.Millisecond), fc, nil)
+	FlushRequestSummary(ctx)
+
+	entries := hook.AllEntries()
+	if len(entries) != 1 || entries[0].Message != "sql request summary" || entries[0].Level != logrus.InfoLevel {
+		t.Fatalf("got %d entries, want the request summary only", len(entries))
+	}
+	entry := entries[0]
+	if entry.Data["service"] != "api" || entry.Data["request_id"] != "r1" {
+		t.Errorf("got fields %v, want the static and context fields", entry.Data)
+	}
+	nested, ok := entry.Data["gorm"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("got fields %v, want the summary fields nested under gorm", entry.Data)
+	}
+	if nested["queries"] != 2 || nested["sql_truncated"] != true {
+		t.Errorf("got the nested fields %v, want 2 queries and the truncated slowest sql", nested)
+	}
+	if sql, _ := nested["slowest_sql"].(string); len(sql) > 40 || sql == long {
+		t.Errorf("got the slowest_sql %q, want it truncated to 40 runes", sql)
+	}
+}
+
+func TestFlushRequestSummaryMaxFields(t *testing.T) {
+	l, hook := newTestLogger(WithSummaryOnly(true), WithStaticFields(logrus.Fields{"a": 1, "b": 2, "c": 3}), WithMaxFields(4))
+	ctx := WithRequestSummary(context.Background())
+	fc, _ := countingTrace("SELECT 1", 1)
+	l.Trace(ctx, time.Now(), fc, nil)
+	FlushRequestSummary(ctx)
+
+	entry := hook.LastEntry()
+	if entry == nil || len(entry.Data) != 4 {
+		t.Fatalf("got %v, want the summary capped to 4 fields", entry)
+	}
+}
@@ -0,0 +1,10 @@
+// Package repository a helper package wrapping gorm, like the repository layer of an application,
+// used by the caller resolution tests
+package repository
+
+import "gorm.io/gorm"
+
+// Exec run sql with db
+func Exec(db *gorm.DB, sql string) error {
+	return db.Exec(sql).Error
+}
@@ -0,0 +1,82 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+// timeoutMessage the message of the failed queries whose error is a timeout
+const timeoutMessage = "query timeout"
+
+// WithQueryTimeoutField log failed queries whose error is a timeout with the "query timeout" message at the
+// error level, never lowered by WithWarnOnFastErrors, tagged with the timeout field, sql, rows and elapsed_ms
+// moving to fields, matched with WithTimeoutMatcher or IsTimeoutError by default
+func WithQueryTimeoutField(enabled bool) Option {
+	return func(opt *options) {
+		opt.timeoutField = enabled
+	}
+}
+
+// WithTimeoutMatcher set the func reporting whether a query error is a timeout, enabling the timeout field
+func WithTimeoutMatcher(matcher func(err error) bool) Option {
+	return func(opt *options) {
+		opt.timeoutField = matcher != nil
+		opt.timeoutMatcher = matcher
+	}
+}
+
+// IsTimeoutError the default timeout matcher, matching deadline exceeded errors
+func IsTimeoutError(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded)
+}
+
+// MatchTimeoutMessage a best-effort timeout matcher, matching IsTimeoutError and
+// the messages of the usual driver statement timeout errors
+func MatchTimeoutMessage(err error) bool {
+	if err == nil {
+		return false
+	}
+	if IsTimeoutError(err) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{
+		"canceling statement due to statement timeout",
+		"maximum statement execution time exceeded",
+		"query execution was interrupted",
+		"i/o timeout",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Logger) isTimeout(err error) bool {
+	if !l.timeoutField {
+		return false
+	}
+	if l.timeoutMatcher != nil {
+		return l.timeoutMatcher(err)
+	}
+	return IsTimeoutError(err)
+}
+
+// WithLockTimeoutMatcher set the func reporting whether a query error is a lock wait timeout,
+// such errors are tagged with the lock_timeout field, see mysqlerr.IsLockTimeout and pgerr.IsLockTimeout
+func WithLockTimeoutMatcher(matcher func(err error) bool) Option {
+	return func(opt *options) {
+		opt.lockTimeoutMatcher = matcher
+	}
+}
+
+// WithLockTimeoutLevel set the level of the lock wait timeout errors, default to the level of the other errors
+func WithLockTimeoutLevel(level logrus.Level) Option {
+	return func(opt *options) {
+		opt.lockTimeoutLevel = &level
+	}
+}
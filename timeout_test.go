@@ -0,0 +1,45 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"testing"
+	"time"
+)
+
+func TestQueryTimeoutMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		timeout bool
+	}{
+		{name: "deadline exceeded", err: context.DeadlineExceeded, timeout: true},
+		{name: "wrapped deadline exceeded", err: fmt.Errorf("query: %w", context.DeadlineExceeded), timeout: true},
+		{name: "other", err: errors.New("syntax error")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, hook := newTestLogger(WithQueryTimeoutField(true), WithWarnOnFastErrors(time.Second))
+			fc, _ := countingTrace("SELECT * FROM users", 0)
+			l.Trace(context.Background(), time.Now(), fc, tt.err)
+			entry := hook.LastEntry()
+			if entry == nil {
+				t.Fatal("got no entry, want the failed query")
+			}
+			if tt.timeout {
+				if entry.Message != timeoutMessage || entry.Level != logrus.ErrorLevel || entry.Data["timeout"] != true || entry.Data["sql"] != "SELECT * FROM users" {
+					t.Errorf("got %s %q %v, want the query timeout message at Error with the timeout and sql fields", entry.Level, entry.Message, entry.Data)
+				}
+				return
+			}
+			if entry.Message == timeoutMessage || entry.Level != logrus.WarnLevel {
+				t.Errorf("got %s %q, want the fast error message at Warn", entry.Level, entry.Message)
+			}
+			if _, ok := entry.Data["timeout"]; ok {
+				t.Errorf("got fields %v, want no timeout field", entry.Data)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package gorm_logrus
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+	"strings"
+	"time"
+)
+
+// traceCall the state of a Trace call shared by its branches
+type traceCall struct {
+	ctx     context.Context
+	begin   time.Time
+	elapsed time.Duration
+	fc      func() (string, int64)
+	err     error
+	summary *requestSummary
+	// file the caller resolved by utils.FileWithLineNum in Trace, when gormCaller
+	file string
+	// slow whether the call is a slow query, once slowDetected, see isSlow
+	slow, slowDetected bool
+
+	// the result of fc, once called
+	called  bool
+	sqlText string
+	rows    int64
+
+	// prepare stats of the fingerprint, see WithPrepareStats
+	prepared, executed int
+}
+
+// result return the sql and rows of the call, calling fc once
+func (t *traceCall) result() (string, int64) {
+	if !t.called {
+		t.sqlText, t.rows = t.fc()
+		t.called = true
+	}
+	return t.sqlText, t.rows
+}
+
+// sql return the sql of the call before any transform
+func (t *traceCall) sql() string {
+	sql, _ := t.result()
+	return sql
+}
+
+// traceError log a failed query
+func (l *Logger) traceError(t *traceCall) {
+	backend := l.branchBackend(BranchError)
+	level := l.branchLevel(BranchError)
+	timeout := l.isTimeout(t.err)
+	if t.elapsed < l.fastErrorThreshold && !timeout {
+		level = logrus.WarnLevel
+	}
+	lockTimeout := l.lockTimeoutMatcher != nil && l.lockTimeoutMatcher(t.err)
+	if lockTimeout && l.lockTimeoutLevel != nil {
+		level = *l.lockTimeoutLevel
+	}
+	if mapped, ok := l.mappedErrorLevel(t.err); ok {
+		level = mapped
+	}
+	if !l.levelEnabled(t.ctx, backend, level) || l.filterErrors && l.filteredSQL(t.sql()) {
+		return
+	}
+	allowed, suppressed := l.errorLimiter.allow()
+	if !allowed {
+		return
+	}
+	if suppressed > 0 {
+		l.logTo(backend, t.ctx, logrus.WarnLevel, logrus.Fields{"suppressed": suppressed}, "%d sql error logs suppressed by the rate limit", suppressed)
+	}
+	sql, rows := t.result()
+	fields := l.traceFields(t)
+	fields[logrus.ErrorKey] = t.err
+	if l.errorTypeField && t.err != nil {
+		fields["error_type"] = fmt.Sprintf("%T", t.err)
+	}
+	if l.errorCodeField {
+		if code, ok := l.errorCode(t.err); ok {
+			fields["error_code"] = code
+		}
+	}
+	msg := l.errorMessage
+	if timeout {
+		fields["timeout"] = true
+		msg = timeoutMessage
+	}
+	if lockTimeout {
+		fields["lock_timeout"] = true
+	}
+	if l.mergeSlowAndError && l.isSlow(t) {
+		fields["slowLog"] = l.slowLog(t)
+		if threshold := l.slowThreshold(t); threshold > 0 {
+			fields["slow_ratio"] = float64(t.elapsed) / float64(threshold)
+		}
+	}
+	sql = l.formatSQL(sql)
+	if l.firstErrorSQLOnly && t.summary != nil {
+		if previous := t.summary.addError(); previous > 0 {
+			sql = fmt.Sprintf("(sql omitted, %d previous errors in this request)", previous)
+		}
+	}
+	l.logTrace(backend, t.ctx, level, fields, msg, t.elapsed, sql, rows)
+}
+
+// slowBranchEnabled report whether a slow query is emitted, before any work is done for it
+func (l *Logger) slowBranchEnabled(ctx context.Context) bool {
+	backend := l.branchBackend(BranchSlow)
+	return l.slowBackend != nil || l.onSlow != nil || l.levelEnabled(ctx, backend, l.slowBranchLevel()) ||
+		l.slowCounts != nil && l.levelEnabled(ctx, backend, logrus.ErrorLevel)
+}
+
+// traceSlow log a slow query
+func (l *Logger) traceSlow(t *traceCall) {
+	backend := l.branchBackend(BranchSlow)
+	sql, rows := t.result()
+	if l.filteredSQL(sql) {
+		return
+	}
+	fields := l.traceFields(t)
+	fields["slowLog"] = l.slowLog(t)
+	if threshold := l.slowThreshold(t); l.slowThresholdFunc == nil && threshold > 0 {
+		fields["elapsed_threshold"] = elapsedMs(threshold)
+	}
+	if l.uniformLevel != nil {
+		fields["slow"] = true
+	}
+	level := l.slowBranchLevel()
+	if l.slowCounts != nil {
+		count := l.slowCounts.inc(fingerprint(sql))
+		fields["slow_count"] = count
+		if count >= l.repeatedSlowThreshold {
+			level = logrus.ErrorLevel
+		}
+	}
+	raw, sql := sql, l.formatSQL(sql)
+	explain := l.explainer.accept(l, raw)
+	if _, ok := fields["query_id"]; explain && !ok {
+		// joins the slow query entry to its plan entry
+		fields["query_id"] = newQueryID()
+	}
+	// logTrace writes into the fields, each backend gets its own copy
+	if l.slowBackend == nil || !l.slowLogOnly {
+		l.logTrace(backend, t.ctx, level, cloneFields(fields), l.slowMessage, t.elapsed, sql, rows)
+	}
+	if l.slowBackend != nil {
+		l.logTrace(l.slowBackend, t.ctx, level, cloneFields(fields), l.slowMessage, t.elapsed, sql, rows)
+		if l.slowLogOnly {
+			// the plan goes with its slow query entry
+			backend = l.slowBackend
+		}
+	}
+	if explain {
+		l.explainer.enqueue(explainJob{ctx: t.ctx, backend: backend, level: level, sql: raw, logged: sql, queryID: fields["query_id"]})
+	}
+	if l.onSlow != nil {
+		l.onSlow(t.ctx, sql, rows, t.elapsed)
+	}
+}
+
+// slowLog return the slowLog field of a slow query
+func (l *Logger) slowLog(t *traceCall) string {
+	if l.slowThresholdFunc != nil {
+		return "SLOW SQL"
+	}
+	return fmt.Sprintf("SLOW SQL >= %v", l.slowThreshold(t))
+}
+
+// slowBranchLevel return the level of the slow queries before their escalation
+func (l *Logger) slowBranchLevel() logrus.Level {
+	if l.slowLevel != nil {
+		return *l.slowLevel
+	}
+	return l.branchLevel(BranchSlow)
+}
+
+// queryBranchEnabled report whether a successful query can be emitted, before any work is done for it
+func (l *Logger) queryBranchEnabled(ctx context.Context) bool {
+	backend := l.branchBackend(BranchQuery)
+	return l.levelEnabled(ctx, backend, l.branchLevel(BranchQuery)) ||
+		l.migrationLevel != nil && l.levelEnabled(ctx, backend, *l.migrationLevel) ||
+		l.warnOnZeroRows != nil && l.levelEnabled(ctx, backend, logrus.WarnLevel)
+}
+
+// traceQuery log a successful query, or only warn about it affecting zero rows when gormLevel is Warn
+// or the request summary replaces the successful queries, see WithWarnOnZeroRows
+func (l *Logger) traceQuery(t *traceCall, gormLevel logger.LogLevel) {
+	backend := l.branchBackend(BranchQuery)
+	// a slow query isn't logged as a successful one when its branch doesn't emit it
+	if !l.queryBranchEnabled(t.ctx) || l.slowLogged(t, gormLevel) {
+		return
+	}
+	sql, rows := t.result()
+	if l.filteredSQL(sql) || rows == 0 && l.suppressZeroRows[sqlOperation(sql)] {
+		return
+	}
+	level := l.branchLevel(BranchQuery)
+	zeroRows := rows == 0 && l.warnOnZeroRows[strings.ToLower(statementVerb(sql))]
+	switch {
+	case zeroRows:
+		level = logrus.WarnLevel
+	case gormLevel < logger.Info || t.summary != nil && l.summaryOnly:
+		return
+	case l.migrationLevel != nil && isDDL(sql):
+		level = *l.migrationLevel
+	}
+	if !l.levelEnabled(t.ctx, backend, level) {
+		return
+	}
+	// the zero rows warnings are neither rate limited nor sampled
+	if !zeroRows {
+		allowed, suppressed := l.queryLimiter.allow()
+		if suppressed > 0 {
+			l.logTo(backend, t.ctx, logrus.WarnLevel, logrus.Fields{"suppressed": suppressed}, "%d sql query logs suppressed by the rate limit", suppressed)
+		}
+		// sampled last so that the sampling report only counts the queries that would have been logged
+		if !allowed || !l.sampler.sample() {
+			return
+		}
+	}
+	fields := l.traceFields(t)
+	if zeroRows {
+		fields["zero_rows"] = true
+	} else if l.sampledField && l.sampler != nil {
+		fields["sampled"] = true
+	}
+	if l.splitStatements {
+		l.traceStatements(t, level, fields, sql, rows)
+		return
+	}
+	l.logTrace(backend, t.ctx, level, fields, l.queryMessage, t.elapsed, l.formatSQL(sql), rows)
+}
+
+// levelEnabled report whether backend emits the traced queries at level, before any work is done for them
+func (l *Logger) levelEnabled(ctx context.Context, backend ContextLogger, level logrus.Level) bool {
+	if l.uniformLevel != nil {
+		level = *l.uniformLevel
+	}
+	return enabled(backend, ctx, level)
+}
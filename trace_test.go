@@ -0,0 +1,124 @@
+package gorm_logrus
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newDisabledLogger return a logger at the Info gorm level, writing to a logrus logger at Info,
+// so that the successful queries, logged at Debug, are dropped
+func newDisabledLogger(opts ...Option) *Logger {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	log.SetLevel(logrus.InfoLevel)
+	return New(append([]Option{WithLogger(log), WithLogLevel(logger.Info)}, opts...)...).(*Logger)
+}
+
+func TestTraceDisabledSkipsTheWork(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "default"},
+		{name: "operation thresholds", opts: []Option{WithOperationSlowThresholds(map[string]time.Duration{"select": time.Hour})}},
+		{name: "slow threshold func", opts: []Option{WithSlowThresholdFunc(func(context.Context, string, time.Duration) bool { return true }), WithSlowLevel(logrus.DebugLevel)}},
+		{name: "audit", opts: []Option{WithAuditMode(true), WithAuditLevel(logrus.DebugLevel)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newDisabledLogger(tt.opts...)
+			fc, calls := countingTrace("SELECT * FROM users", 1)
+			ctx := context.Background()
+			l.Trace(ctx, time.Now(), fc, nil)
+			if *calls != 0 {
+				t.Errorf("fc called %d times, want none", *calls)
+			}
+			if allocs := testing.AllocsPerRun(100, func() { l.Trace(ctx, time.Now(), fc, nil) }); allocs != 0 {
+				t.Errorf("got %v allocations per Trace, want none", allocs)
+			}
+		})
+	}
+}
+
+func TestTraceOperationThresholdsResolvedLazily(t *testing.T) {
+	l, hook := newTestLogger(WithSlowThreshold(time.Hour), WithOperationSlowThresholds(map[string]time.Duration{"update": 10 * time.Millisecond}))
+	fc, calls := countingTrace("SELECT * FROM users", 1)
+	l.LogMode(logger.Warn).Trace(context.Background(), time.Now(), fc, nil)
+	if *calls != 0 || len(hook.AllEntries()) != 0 {
+		t.Errorf("fast query: got %d entries and %d fc calls, want none", len(hook.AllEntries()), *calls)
+	}
+
+	fc, _ = countingTrace("UPDATE users SET name = 'a'", 1)
+	l.LogMode(logger.Warn).Trace(context.Background(), slowBegin(10*time.Millisecond), fc, nil)
+	if entry := hook.LastEntry(); entry == nil || entry.Level != logrus.WarnLevel || entry.Data["elapsed_threshold"] != 10.0 {
+		t.Errorf("got %v, want the UPDATE logged as slow over its operation threshold", entry)
+	}
+}
+
+func BenchmarkTraceDisabled(b *testing.B) {
+	l := newDisabledLogger()
+	ctx := context.Background()
+	fc := func() (string, int64) { return "SELECT * FROM users WHERE id = 1", 1 }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Trace(ctx, time.Now(), fc, nil)
+	}
+}
+
+func BenchmarkTraceEnabled(b *testing.B) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	log.SetLevel(logrus.DebugLevel)
+	l := New(WithLogger(log), WithLogLevel(logger.Info))
+	ctx := context.Background()
+	fc := func() (string, int64) { return "SELECT * FROM users WHERE id = 1", 1 }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Trace(ctx, time.Now(), fc, nil)
+	}
+}
+
+func TestSlowThresholdFuncAndSlowLevel(t *testing.T) {
+	type ctxKey struct{}
+	l, hook := newTestLogger(WithSlowLevel(logrus.ErrorLevel), WithSlowThresholdFunc(func(ctx context.Context, sql string, elapsed time.Duration) bool {
+		if ctx.Value(ctxKey{}) != nil || strings.HasPrefix(sql, "SELECT SUM") {
+			return elapsed > time.Hour
+		}
+		return elapsed > 50*time.Millisecond
+	}))
+	ctx := context.Background()
+	tests := []struct {
+		ctx  context.Context
+		sql  string
+		want logrus.Level
+	}{
+		{ctx: ctx, sql: "SELECT * FROM `users` WHERE `id` = 1", want: logrus.ErrorLevel},
+		{ctx: ctx, sql: "SELECT SUM(`total`) FROM `orders`", want: logrus.DebugLevel},
+		{ctx: context.WithValue(ctx, ctxKey{}, true), sql: "SELECT * FROM `users` WHERE `id` = 1", want: logrus.DebugLevel},
+	}
+	for _, tt := range tests {
+		l.Trace(tt.ctx, slowBegin(time.Second), func() (string, int64) { return tt.sql, 1 }, nil)
+		entry := hook.LastEntry()
+		if entry.Level != tt.want {
+			t.Errorf("got %s for %s, want %s", entry.Level, tt.sql, tt.want)
+		}
+		if _, ok := entry.Data["elapsed_threshold"]; ok {
+			t.Errorf("got fields %v, want no elapsed_threshold with the threshold func", entry.Data)
+		}
+	}
+}
+
+func TestSlowElapsedThresholdField(t *testing.T) {
+	l, hook := newTestLogger(WithSlowThreshold(200 * time.Millisecond))
+	l.Trace(context.Background(), slowBegin(time.Second), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	if got := hook.LastEntry().Data["elapsed_threshold"]; got != 200.0 {
+		t.Errorf("got elapsed_threshold %v, want 200", got)
+	}
+}
@@ -0,0 +1,35 @@
+package gorm_logrus
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+	"strings"
+)
+
+// NewWriter create a logger with the same signature as gorm's logger.New, easing the swap-in,
+// a *logrus.Logger writer is logged to directly, any other writer receives through Printf the
+// logrus entries at their mapped level, formatted as text without timestamp, colored when cfg.Colorful
+func NewWriter(writer logger.Writer, cfg logger.Config) logger.Interface {
+	switch w := writer.(type) {
+	case nil:
+		return New(WithConfig(cfg))
+	case *logrus.Logger:
+		return New(WithLogger(w), WithConfig(cfg))
+	default:
+		log := logrus.New()
+		log.SetOutput(printfWriter{writer: w})
+		log.SetLevel(logrus.TraceLevel)
+		log.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true, ForceColors: cfg.Colorful})
+		return New(WithLogger(log), WithConfig(cfg))
+	}
+}
+
+// printfWriter io.Writer printing each formatted logrus entry to a logger.Writer
+type printfWriter struct {
+	writer logger.Writer
+}
+
+func (w printfWriter) Write(p []byte) (int, error) {
+	w.writer.Printf("%s", strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
@@ -0,0 +1,41 @@
+package gorm_logrus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+// printfRecorder a logger.Writer recording the Printf lines
+type printfRecorder struct {
+	lines []string
+}
+
+func (r *printfRecorder) Printf(format string, args ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func TestNewWriterBridgesThroughLogrus(t *testing.T) {
+	w := &printfRecorder{}
+	l := NewWriter(w, logger.Config{LogLevel: logger.Warn})
+
+	ctx := context.Background()
+	l.Info(ctx, "info message")
+	l.Warn(ctx, "warn %s", "message")
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 2", 0 }, errors.New("failed"))
+
+	if len(w.lines) != 2 {
+		t.Fatalf("got %q, want the warning and the error only", w.lines)
+	}
+	if line := w.lines[0]; !strings.Contains(line, "level=warning") || !strings.Contains(line, `msg="warn message"`) {
+		t.Errorf("got %q, want the warning formatted by logrus", line)
+	}
+	if line := w.lines[1]; !strings.Contains(line, "level=error") || !strings.Contains(line, "error=failed") || strings.HasSuffix(line, "\n") {
+		t.Errorf("got %q, want the error formatted by logrus without the trailing newline", line)
+	}
+}